@@ -0,0 +1,66 @@
+package xmux
+
+import "context"
+
+// RouteBuilder accumulates the method, path, options and middleware of a
+// route and registers it with Handle. Route is taken by the RouteDef
+// constructor, so builders are created with NewRoute.
+//
+// Example:
+//
+//	xmux.Handle(xmux.NewRoute(r).
+//	    Method(http.MethodPost).
+//	    Path("/users").
+//	    Options(xmux.Consumes("application/json")).
+//	    Use(audit),
+//	    svc.CreateUser)
+type RouteBuilder struct {
+	router     Router
+	method     string
+	path       string
+	options    []map[string]string
+	middleware []Middleware
+}
+
+// NewRoute starts building a route registered with router, such as the
+// router passed to a ServiceGroup.
+func NewRoute(router Router) *RouteBuilder {
+	return &RouteBuilder{router: router}
+}
+
+// Method sets the HTTP method of the route.
+func (b *RouteBuilder) Method(method string) *RouteBuilder {
+	b.method = method
+	return b
+}
+
+// Path sets the URL path pattern of the route.
+func (b *RouteBuilder) Path(path string) *RouteBuilder {
+	b.path = path
+	return b
+}
+
+// Options appends route options.
+func (b *RouteBuilder) Options(options ...map[string]string) *RouteBuilder {
+	b.options = append(b.options, options...)
+	return b
+}
+
+// Use appends middleware wrapping this route only. The first middleware
+// is the outermost one, as with Chain.
+func (b *RouteBuilder) Use(middleware ...Middleware) *RouteBuilder {
+	b.middleware = append(b.middleware, middleware...)
+	return b
+}
+
+// HandleApi registers api as the handler of the route.
+func (b *RouteBuilder) HandleApi(api Api) {
+	b.router.Register(b.method, b.path, Chain(api, b.middleware...), b.options...)
+}
+
+// Handle registers a business logic function as the handler of the route
+// built by b. It is a function rather than a method of RouteBuilder,
+// because methods cannot have type parameters.
+func Handle[Params any, Response any](b *RouteBuilder, fn func(ctx context.Context, params *Params) (Response, error)) {
+	b.HandleApi(NewHandler(fn))
+}
@@ -0,0 +1,219 @@
+// Package codegen describes xmux routes in a flat, tool friendly shape
+// for client code generators. The output of DescribeRoutes marshals to
+// stable JSON: routes keep their registration order and fields keep
+// their declaration order.
+//
+// Example:
+//
+//	routes, err := codegen.Collect(groups, bindService)
+//	if err != nil { ... }
+//	out, _ := json.MarshalIndent(codegen.DescribeRoutes(routes), "", "  ")
+package codegen
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Just-maple/xmux"
+)
+
+// RouteSchema describes a single route.
+type RouteSchema struct {
+	Method   string      `json:"method"`
+	Path     string      `json:"path"`
+	Name     string      `json:"name,omitempty"`
+	Params   *TypeSchema `json:"params"`
+	Response *TypeSchema `json:"response"`
+}
+
+// TypeSchema describes a Go type.
+type TypeSchema struct {
+	// Name is the Go type name, empty for unnamed types
+	Name string `json:"name,omitempty"`
+
+	// Kind is one of string, integer, number, boolean, time, array, map,
+	// object or any
+	Kind string `json:"kind"`
+
+	// Nullable is set for pointer types
+	Nullable bool `json:"nullable,omitempty"`
+
+	// Fields lists the fields of an object
+	Fields []FieldSchema `json:"fields,omitempty"`
+
+	// Elem is the element type of an array or the value type of a map
+	Elem *TypeSchema `json:"elem,omitempty"`
+
+	// Ref names an object already described higher up, for recursive types
+	Ref string `json:"ref,omitempty"`
+}
+
+// FieldSchema describes a struct field.
+type FieldSchema struct {
+	// Name is the Go field name
+	Name string `json:"name"`
+
+	// JSON is the name of the field in the request or response
+	JSON string `json:"json"`
+
+	// In is the request part the field is bound from: body, query, path
+	// or header
+	In string `json:"in"`
+
+	// Required is set for fields without omitempty that are not pointers,
+	// and for fields with a `validate:"required"` rule
+	Required bool `json:"required"`
+
+	Type *TypeSchema `json:"type"`
+}
+
+// DescribeRoutes describes the params and response types of routes.
+func DescribeRoutes(routes []xmux.RouteDef) []RouteSchema {
+	schemas := make([]RouteSchema, 0, len(routes))
+	for _, route := range routes {
+		schemas = append(schemas, RouteSchema{
+			Method:   route.Method,
+			Path:     route.Path,
+			Name:     route.Api.Name(),
+			Params:   describe(typeOf(route.Api.Params()), map[reflect.Type]bool{}),
+			Response: describe(typeOf(route.Api.Response()), map[reflect.Type]bool{}),
+		})
+	}
+	return schemas
+}
+
+// Collect binds binder against a recording controller and returns its
+// routes in registration order. bind injects services as for any Binder.
+func Collect(binder xmux.Binder, bind func(service any) error) ([]xmux.RouteDef, error) {
+	var (
+		mu     sync.Mutex
+		routes []xmux.RouteDef
+	)
+	err := binder.Bind(controllerFunc(func(method string, path string, api xmux.Api, options ...map[string]string) {
+		mu.Lock()
+		routes = append(routes, xmux.RouteDef{Method: method, Path: path, Api: api, Options: options})
+		mu.Unlock()
+	}), bind)
+	return routes, err
+}
+
+// typeOf returns the dynamic type of v, or the empty interface type for nil.
+func typeOf(v any) reflect.Type {
+	if t := reflect.TypeOf(v); t != nil {
+		return t
+	}
+	return reflect.TypeOf((*any)(nil)).Elem()
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// describe returns the schema of t. seen holds the struct types being
+// described, to stop at recursive references.
+func describe(t reflect.Type, seen map[reflect.Type]bool) *TypeSchema {
+	if t.Kind() == reflect.Pointer {
+		schema := describe(t.Elem(), seen)
+		schema.Nullable = true
+		return schema
+	}
+	schema := &TypeSchema{Name: t.Name()}
+	if t.PkgPath() != "" {
+		schema.Name = t.String()
+	}
+	if t == timeType {
+		schema.Kind = "time"
+		return schema
+	}
+	switch t.Kind() {
+	case reflect.String:
+		schema.Kind = "string"
+	case reflect.Bool:
+		schema.Kind = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema.Kind = "integer"
+	case reflect.Float32, reflect.Float64:
+		schema.Kind = "number"
+	case reflect.Slice, reflect.Array:
+		schema.Kind = "array"
+		schema.Elem = describe(t.Elem(), seen)
+	case reflect.Map:
+		schema.Kind = "map"
+		schema.Elem = describe(t.Elem(), seen)
+	case reflect.Struct:
+		schema.Kind = "object"
+		if seen[t] {
+			schema.Ref = schema.Name
+			return schema
+		}
+		seen[t] = true
+		schema.Fields = describeFields(t, seen)
+		delete(seen, t)
+	default:
+		schema.Kind = "any"
+	}
+	return schema
+}
+
+// describeFields returns the schemas of the fields of struct type t.
+// Untagged embedded structs are flattened as encoding/json does.
+func describeFields(t reflect.Type, seen map[reflect.Type]bool) []FieldSchema {
+	var fields []FieldSchema
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonName, jsonOpts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && jsonName == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, describeFields(embedded, seen)...)
+				continue
+			}
+		}
+		if !field.IsExported() || jsonName == "-" && field.Tag.Get("query") == "" && field.Tag.Get("path") == "" && field.Tag.Get("header") == "" {
+			continue
+		}
+
+		schema := FieldSchema{Name: field.Name, JSON: jsonName, In: "body"}
+		if schema.JSON == "" || schema.JSON == "-" {
+			schema.JSON = field.Name
+		}
+		for _, in := range []string{"path", "query", "header"} {
+			if value, ok := field.Tag.Lookup(in); ok && !strings.HasPrefix(value, "-") {
+				name, _, _ := strings.Cut(value, ",")
+				schema.In, schema.JSON = in, name
+				break
+			}
+		}
+		if in := field.Tag.Get("in"); in != "" {
+			schema.In = in
+		}
+		// A `query:"*"` catch-all collects optional, unnamed query values
+		schema.Required = schema.JSON != "*" && (field.Type.Kind() != reflect.Pointer && !strings.Contains(jsonOpts, "omitempty") ||
+			hasRule(field.Tag.Get("validate"), "required"))
+		schema.Type = describe(field.Type, seen)
+		fields = append(fields, schema)
+	}
+	return fields
+}
+
+// hasRule reports whether the comma separated validate tag has rule.
+func hasRule(tag string, rule string) bool {
+	for _, r := range strings.Split(tag, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// controllerFunc adapts a function to xmux.Controller.
+type controllerFunc func(method string, path string, api xmux.Api, options ...map[string]string)
+
+// Handle implements xmux.Controller.
+func (fn controllerFunc) Handle(method string, path string, api xmux.Api, options ...map[string]string) {
+	fn(method, path, api, options...)
+}
@@ -0,0 +1,60 @@
+package xmux
+
+import "strings"
+
+// OptionConsumes is the route option key listing the request content
+// types a route accepts, separated by commas.
+const OptionConsumes = "consumes"
+
+// Consumes returns a route option restricting the request Content-Type.
+// Adapters reject requests with a body of any other type with
+// 415 Unsupported Media Type. Routes without the option accept any type.
+//
+// Example:
+//
+//	xmux.Register(r, http.MethodPost, "/users", svc.CreateUser, xmux.Consumes("application/json"))
+func Consumes(contentTypes ...string) map[string]string {
+	return map[string]string{OptionConsumes: strings.Join(contentTypes, ",")}
+}
+
+// OptionProduces is the route option key listing the response content
+// types a route can emit, separated by commas.
+const OptionProduces = "produces"
+
+// Produces returns a route option declaring the response content types of
+// a route. Adapters reject requests whose Accept header matches none of
+// them with 406 Not Acceptable. Routes without the option are not checked.
+//
+// Example:
+//
+//	xmux.Register(r, http.MethodGet, "/users", svc.ListUsers, xmux.Produces("application/json"))
+func Produces(contentTypes ...string) map[string]string {
+	return map[string]string{OptionProduces: strings.Join(contentTypes, ",")}
+}
+
+// OptionRequireBody is the route option key making ("true") an empty
+// request body a bind error.
+const OptionRequireBody = "require_body"
+
+// RequireBody returns a route option rejecting requests without a body
+// with 400 Bad Request. Routes without it treat an empty body as none
+// provided, leaving the params zero.
+//
+// Example:
+//
+//	xmux.Register(r, http.MethodPost, "/users", svc.CreateUser, xmux.RequireBody())
+func RequireBody() map[string]string {
+	return map[string]string{OptionRequireBody: "true"}
+}
+
+// SplitOption splits a comma separated option value, trimming spaces and
+// dropping empty entries.
+func SplitOption(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,217 @@
+package xmux
+
+import (
+	"context"
+	"net/http"
+)
+
+// contextKey is the type of context keys defined by xmux.
+// Using an unexported type prevents collisions with keys from other packages.
+type contextKey int
+
+const (
+	routeKey contextKey = iota
+	roleKey
+	requestKey
+	requestIDKey
+	responseHeaderKey
+	subjectKey
+	callerKey
+)
+
+// RequestInfo describes the inbound HTTP request.
+// Adapters place it in the context so framework agnostic middleware
+// can inspect headers and the client address.
+type RequestInfo struct {
+	// Method is the HTTP method of the request
+	Method string
+
+	// Path is the concrete request path (e.g., "/users/42")
+	Path string
+
+	// Header holds the request headers
+	Header http.Header
+
+	// RemoteAddr is the network address of the client
+	RemoteAddr string
+}
+
+// WithRequestInfo returns a copy of ctx carrying the request information.
+func WithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestKey, info)
+}
+
+// RequestInfoFromContext returns the request information stored in ctx.
+func RequestInfoFromContext(ctx context.Context) (*RequestInfo, bool) {
+	info, ok := ctx.Value(requestKey).(*RequestInfo)
+	return info, ok
+}
+
+// routeInfo describes the route a request was matched to.
+type routeInfo struct {
+	method  string
+	path    string
+	options map[string]string
+}
+
+// withRoute returns a copy of ctx carrying the route information.
+func withRoute(ctx context.Context, route routeInfo) context.Context {
+	return context.WithValue(ctx, routeKey, route)
+}
+
+// routeFromContext returns the route information stored in ctx.
+func routeFromContext(ctx context.Context) (routeInfo, bool) {
+	route, ok := ctx.Value(routeKey).(routeInfo)
+	return route, ok
+}
+
+// WithRoute returns a copy of ctx carrying the route a request was
+// matched to: the HTTP method, the route pattern and the merged route
+// options. Adapters call it before invoking the Api.
+func WithRoute(ctx context.Context, method string, path string, options map[string]string) context.Context {
+	return withRoute(ctx, routeInfo{method: method, path: path, options: options})
+}
+
+// RoutePatternFromContext returns the pattern the request was matched to
+// as registered (e.g., "/users/:id"), not the concrete request path.
+// Use it for low-cardinality log fields and metrics labels.
+//
+// Example:
+//
+//	if pattern, ok := xmux.RoutePatternFromContext(ctx); ok {
+//	    requests.WithLabelValues(pattern).Inc()
+//	}
+func RoutePatternFromContext(ctx context.Context) (string, bool) {
+	route, ok := routeFromContext(ctx)
+	return route.path, ok
+}
+
+// RouteOptionsFromContext returns the merged options of the route the
+// request was matched to, or nil if ctx carries no route.
+// The returned map is shared and must not be modified.
+//
+// Example:
+//
+//	if xmux.RouteOptionsFromContext(ctx)["public"] == "true" {
+//	    return next(ctx, bind)
+//	}
+func RouteOptionsFromContext(ctx context.Context) map[string]string {
+	route, _ := routeFromContext(ctx)
+	return route.options
+}
+
+// WithRole returns a copy of ctx carrying the authenticated role.
+// Authentication middleware calls this so that authorization
+// middleware like RequireRoles can inspect the caller's role.
+func WithRole(ctx context.Context, role string) context.Context {
+	if caller, ok := ctx.Value(callerKey).(*Caller); ok {
+		caller.Role = role
+	}
+	return context.WithValue(ctx, roleKey, role)
+}
+
+// RoleFromContext returns the authenticated role stored in ctx.
+func RoleFromContext(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(roleKey).(string)
+	return role, ok
+}
+
+// WithSubject returns a copy of ctx carrying the id of the authenticated
+// caller, e.g. the user id. Authentication middleware calls this so that
+// fields tagged `visible:"self"` are shown to their owner.
+func WithSubject(ctx context.Context, subject string) context.Context {
+	if caller, ok := ctx.Value(callerKey).(*Caller); ok {
+		caller.Subject = subject
+	}
+	return context.WithValue(ctx, subjectKey, subject)
+}
+
+// SubjectFromContext returns the authenticated subject stored in ctx.
+func SubjectFromContext(ctx context.Context) (string, bool) {
+	subject, ok := ctx.Value(subjectKey).(string)
+	return subject, ok
+}
+
+// Caller is the authenticated caller of a request, as set with WithRole
+// and WithSubject.
+type Caller struct {
+	Role    string
+	Subject string
+}
+
+// RecordCaller returns a copy of ctx in which WithRole and WithSubject
+// also record the caller into c, which starts as the caller of ctx.
+// Middleware sets the caller on contexts derived from the one it
+// receives, so adapters call this before invoking the Api to learn the
+// caller a response is rendered for.
+func RecordCaller(ctx context.Context, c *Caller) context.Context {
+	c.Role, _ = RoleFromContext(ctx)
+	c.Subject, _ = SubjectFromContext(ctx)
+	return context.WithValue(ctx, callerKey, c)
+}
+
+// claimsKey is the context key of claims of type T. Each T gets its own
+// key, so claims of different types never collide.
+type claimsKey[T any] struct{}
+
+// WithClaims returns a copy of ctx carrying the claims of the
+// authenticated caller. Authentication middleware calls this with the
+// claim type of the application; handlers read them back with
+// ClaimsFromContext using the same type.
+//
+// Example:
+//
+//	ctx = xmux.WithClaims(ctx, claims) // claims is *auth.Claims
+func WithClaims[T any](ctx context.Context, claims T) context.Context {
+	return context.WithValue(ctx, claimsKey[T]{}, claims)
+}
+
+// ClaimsFromContext returns the claims of type T stored in ctx by
+// WithClaims. It reports false if ctx carries no claims of that exact
+// type, e.g. when the middleware stored Claims and the handler asks for
+// *Claims.
+//
+// Example:
+//
+//	claims, ok := xmux.ClaimsFromContext[*auth.Claims](ctx)
+func ClaimsFromContext[T any](ctx context.Context) (T, bool) {
+	claims, ok := ctx.Value(claimsKey[T]{}).(T)
+	return claims, ok
+}
+
+// WithRequestID returns a copy of ctx carrying the request id used to
+// correlate logs and error responses.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFromContext returns the request id stored in ctx.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok
+}
+
+// WithResponseHeader returns a copy of ctx carrying header, the headers
+// of the response. Adapters call it before invoking the Api and copy
+// header to the response before writing the body, so SetResponseHeader
+// works with any framework.
+func WithResponseHeader(ctx context.Context, header http.Header) context.Context {
+	return context.WithValue(ctx, responseHeaderKey, header)
+}
+
+// SetResponseHeader sets a response header from a handler or middleware,
+// e.g. Cache-Control, or a Link preload hint. The headers are written
+// with successful responses only; errors carry theirs in an HTTPError.
+// It reports false if ctx carries no response headers (see
+// WithResponseHeader).
+//
+// Example:
+//
+//	xmux.SetResponseHeader(ctx, "Link", "</style.css>; rel=preload; as=style")
+func SetResponseHeader(ctx context.Context, key string, value string) bool {
+	header, ok := ctx.Value(responseHeaderKey).(http.Header)
+	if ok {
+		header.Set(key, value)
+	}
+	return ok
+}
@@ -0,0 +1,179 @@
+package xmux
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"reflect"
+	"strings"
+)
+
+// Redacted replaces the value of sensitive fields in logged bodies.
+const Redacted = "[REDACTED]"
+
+// DefaultRedactedFields are the field names redacted by LogBodies in
+// addition to fields tagged `sensitive:"true"`. A field is redacted when
+// its JSON name contains one of them, ignoring case, so "password" also
+// covers "new_password".
+var DefaultRedactedFields = []string{"password", "secret", "token"}
+
+// BodyLogConfig configures LogBodies.
+type BodyLogConfig struct {
+	// Logf writes a log line, log.Printf if nil
+	Logf func(format string, args ...any)
+
+	// RedactedFields replaces DefaultRedactedFields if not nil
+	RedactedFields []string
+}
+
+// LogBodies returns a debug middleware logging the bound params and the
+// result or error of every request. Fields tagged `sensitive:"true"`, or
+// whose JSON name matches a redacted field name, are logged as Redacted.
+//
+// Params are logged after binding, so the request body is read only once,
+// by the regular binding. The middleware is strictly opt-in and meant for
+// debugging: it marshals every body.
+//
+// Example:
+//
+//	debug := xmux.Use(userGroup, xmux.LogBodies(xmux.BodyLogConfig{}))
+func LogBodies(config BodyLogConfig) Middleware {
+	logf := config.Logf
+	if logf == nil {
+		logf = log.Printf
+	}
+	redacted := config.RedactedFields
+	if redacted == nil {
+		redacted = DefaultRedactedFields
+	}
+	names := make([]string, len(redacted))
+	for i, name := range redacted {
+		names[i] = strings.ToLower(name)
+	}
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, bind func(params any) error) (any, error) {
+			route, _ := routeFromContext(ctx)
+			result, err := next(ctx, func(params any) error {
+				if err := bind(params); err != nil {
+					return err
+				}
+				logf("%s %s request: %s", route.method, route.path, redactJSON(params, names))
+				return nil
+			})
+			if err != nil {
+				logf("%s %s error: %v", route.method, route.path, err)
+			} else {
+				logf("%s %s response: %s", route.method, route.path, redactJSON(result, names))
+			}
+			return result, err
+		}
+	}
+}
+
+// redactJSON marshals v with sensitive fields redacted.
+func redactJSON(v any, names []string) string {
+	b, err := json.Marshal(redact(reflect.ValueOf(v), names))
+	if err != nil {
+		return "<" + err.Error() + ">"
+	}
+	return string(b)
+}
+
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// redact returns a copy of v safe for logging: structs become maps keyed
+// by JSON name with sensitive fields replaced by Redacted.
+func redact(v reflect.Value, names []string) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type().Implements(marshalerType) {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redact(v.Elem(), names)
+	case reflect.Struct:
+		out := make(map[string]any, v.NumField())
+		redactStruct(v, names, out)
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = redact(v.Index(i), names)
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, _ := json.Marshal(iter.Key().Interface())
+			name := strings.Trim(string(key), `"`)
+			if redactedName(name, names) {
+				out[name] = Redacted
+				continue
+			}
+			out[name] = redact(iter.Value(), names)
+		}
+		return out
+	default:
+		if v.CanInterface() {
+			return v.Interface()
+		}
+		return nil
+	}
+}
+
+// redactStruct adds the fields of struct v to out, flattening untagged
+// embedded structs as encoding/json does.
+func redactStruct(v reflect.Value, names []string, out map[string]any) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if field.Anonymous && name == "" {
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				redactStruct(fv, names, out)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if field.Tag.Get("sensitive") == "true" || redactedName(name, names) {
+			out[name] = Redacted
+			continue
+		}
+		out[name] = redact(fv, names)
+	}
+}
+
+// redactedName reports whether name contains one of names, ignoring case.
+func redactedName(name string, names []string) bool {
+	name = strings.ToLower(name)
+	for _, n := range names {
+		if strings.Contains(name, n) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package xmux
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// depsGroup is a group of routes sharing a struct of dependencies.
+// Each exported field of Deps is resolved separately through bind.
+type depsGroup[Deps any] struct {
+	// register is the function that defines routes for this group
+	register func(router Router, deps Deps)
+
+	// options are route-level options that apply to all routes in this group
+	options []map[string]string
+}
+
+// Bind resolves every dependency and registers all routes in the group.
+// This implements the Binder interface.
+func (g depsGroup[Deps]) Bind(controller Controller, bind func(any) error) (err error) {
+	var deps Deps
+	if err = resolveDeps(&deps, bind); err != nil {
+		return
+	}
+	g.register(registerFunc(func(method string, path string, api Api, options ...map[string]string) {
+		controller.Handle(method, path, serviceApi[Deps]{
+			Api:  api,
+			impl: deps,
+		}, joinOptions(g.options, options)...)
+	}), deps)
+	return
+}
+
+// resolveDeps calls bind with a pointer to every exported field of the
+// struct ptr points to. A pointer, interface, map, func or chan field
+// still nil after bind returns is reported as unresolved.
+func resolveDeps(ptr any, bind func(any) error) error {
+	v := reflect.ValueOf(ptr).Elem()
+	t := v.Type()
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("xmux: dependencies %s must be a struct", t)
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if err := bind(fv.Addr().Interface()); err != nil {
+			return fmt.Errorf("xmux: resolve %s.%s (%s): %w", t, field.Name, field.Type, err)
+		}
+		switch fv.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Func, reflect.Chan:
+			if fv.IsNil() {
+				return fmt.Errorf("xmux: resolve %s.%s (%s): not provided", t, field.Name, field.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// ServiceGroupDeps creates a route group depending on several services.
+// Deps is a struct whose exported fields are resolved one by one through
+// the bind function, as if each was the service of a ServiceGroup, so a
+// group can use a user service, a logger and a token issuer together.
+// Binding fails with an error naming the field if one cannot be resolved.
+//
+// Type parameters:
+//   - Deps: a struct of the group's dependencies
+//
+// Example:
+//
+//	type userDeps struct {
+//	    Users  *UserService
+//	    Tokens *auth.TokenService
+//	}
+//	userGroup := xmux.ServiceGroupDeps(func(router xmux.Router, d userDeps) {
+//	    xmux.Register(router, http.MethodPost, "/login", d.Users.Login)
+//	    xmux.Register(router, http.MethodPost, "/refresh", d.Tokens.Refresh)
+//	})
+func ServiceGroupDeps[Deps any](fn func(r Router, d Deps), options ...map[string]string) Binder {
+	return depsGroup[Deps]{
+		options:  options,
+		register: fn,
+	}
+}
@@ -0,0 +1,37 @@
+package xmux
+
+import "strconv"
+
+// OptionEnvelope is the route option key enabling ("true") or disabling
+// ("false") response enveloping, overriding the adapter default.
+const OptionEnvelope = "envelope"
+
+// WithEnvelope returns a route option enabling or disabling response
+// enveloping for a route, e.g. to opt a file download out of a global
+// envelope.
+//
+// Example:
+//
+//	xmux.Register(r, http.MethodGet, "/export", svc.Export, xmux.WithEnvelope(false))
+func WithEnvelope(enabled bool) map[string]string {
+	return map[string]string{OptionEnvelope: strconv.FormatBool(enabled)}
+}
+
+// Envelope is the standard response wrapper: {"data": ..., "meta": ...}.
+type Envelope struct {
+	Data any `json:"data"`
+	Meta any `json:"meta,omitempty"`
+}
+
+// PageMeta describes a page of a paginated list.
+type PageMeta struct {
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// Paginated is implemented by list responses carrying pagination
+// metadata. Adapters place the PageMeta in Envelope.Meta.
+type Paginated interface {
+	Page() PageMeta
+}
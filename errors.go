@@ -0,0 +1,207 @@
+package xmux
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// HTTPError is an error carrying the HTTP status code an adapter should
+// respond with. Middleware and business logic return it to signal
+// conditions such as 403 Forbidden without depending on a framework.
+type HTTPError struct {
+	// Status is the HTTP status code
+	Status int
+
+	// Message is the client facing error message
+	Message string
+
+	// Header holds additional response headers (e.g., Retry-After)
+	Header http.Header
+}
+
+// Error implements the error interface.
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
+// StatusCode returns the HTTP status code of the error.
+func (e *HTTPError) StatusCode() int {
+	return e.Status
+}
+
+// NewError creates an HTTPError with the given status code and message.
+// If message is empty, the standard status text is used.
+func NewError(status int, message string) *HTTPError {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &HTTPError{Status: status, Message: message}
+}
+
+// DomainError is a business error carrying a stable, machine readable
+// code besides its message, so clients can branch on the code rather
+// than on the message. Adapters respond with HTTPStatus and include Code
+// in the error body.
+type DomainError struct {
+	// Code is the stable error code (e.g., "user_not_found")
+	Code string
+
+	// Message is the client facing error message
+	Message string
+
+	// HTTPStatus is the HTTP status code
+	HTTPStatus int
+}
+
+// Error implements the error interface.
+func (e *DomainError) Error() string {
+	return e.Message
+}
+
+// StatusCode returns the HTTP status code of the error.
+func (e *DomainError) StatusCode() int {
+	return e.HTTPStatus
+}
+
+// ErrorCode returns the code of the error.
+func (e *DomainError) ErrorCode() string {
+	return e.Code
+}
+
+// NewDomainError creates a DomainError with the given code, status code
+// and message. If message is empty, the standard status text is used.
+//
+// Example:
+//
+//	var ErrUserNotFound = xmux.NewDomainError("user_not_found", http.StatusNotFound, "user not found")
+func NewDomainError(code string, status int, message string) *DomainError {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &DomainError{Code: code, Message: message, HTTPStatus: status}
+}
+
+// ErrForbidden is returned when the caller is not allowed to access a route.
+var ErrForbidden = NewError(http.StatusForbidden, "")
+
+// StatusCode returns the HTTP status code carried by err.
+// Returns fallback if no positive status code is found.
+func StatusCode(err error, fallback int) int {
+	var coder interface{ StatusCode() int }
+	if errors.As(err, &coder) {
+		if status := coder.StatusCode(); status > 0 {
+			return status
+		}
+	}
+	return fallback
+}
+
+// ErrorCode returns the code carried by err, such as the Code of a
+// DomainError, or an empty string if err carries none.
+func ErrorCode(err error) string {
+	var coder interface{ ErrorCode() string }
+	if errors.As(err, &coder) {
+		return coder.ErrorCode()
+	}
+	return ""
+}
+
+// BindError reports a failure to bind request data into handler params.
+// Adapters respond with 400 Bad Request.
+type BindError struct {
+	// Type is the kind of failure, usually the request part being bound
+	// (e.g., "body", "query", "path")
+	Type string
+
+	// Field is the param field that failed, if any, as a dotted path for
+	// fields of embedded structs (e.g., "CreateUserRequest.Email")
+	Field string
+
+	// Err is the underlying error
+	Err error
+}
+
+// Error implements the error interface.
+func (e *BindError) Error() string {
+	if e.Field == "" {
+		return "bind " + e.Type + ": " + e.Err.Error()
+	}
+	return "bind " + e.Type + " field " + e.Field + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns 400 Bad Request.
+func (e *BindError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// ValidationError reports every param field failing validation.
+// Adapters respond with 400 Bad Request, or the status they are
+// configured with for validation failures, and list the fields.
+type ValidationError struct {
+	// Fields maps the request name of each failing field (e.g., its JSON
+	// name) to its message
+	Fields map[string]string
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		names[i] = name + " " + e.Fields[name]
+	}
+	return "validation failed: " + strings.Join(names, "; ")
+}
+
+// StatusCode returns 400 Bad Request.
+func (e *ValidationError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// PartialError is an error carrying a structured response body, for
+// handlers that must fail but still return data such as a partial result
+// or per-item failures. Adapters respond with Status and encode Body in
+// place of the standard error body.
+//
+// The value returned alongside any non-nil error is always ignored, so
+// the body must be carried by the PartialError itself:
+//
+//	return nil, &xmux.PartialError{Status: http.StatusMultiStatus, Body: results, Err: errSomeFailed}
+type PartialError struct {
+	// Status is the HTTP status code
+	Status int
+
+	// Body is the response body
+	Body any
+
+	// Err is the underlying error
+	Err error
+}
+
+// Error implements the error interface.
+func (e *PartialError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Status)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the HTTP status code of the error.
+func (e *PartialError) StatusCode() int {
+	return e.Status
+}
@@ -1,47 +1,52 @@
 package main
 
 import (
-	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/Just-maple/xmux"
+	"github.com/Just-maple/xmux/xhttp"
 )
 
 // Controller adapts Chi to xmux.Controller interface.
 type Controller struct {
-	mux *chi.Mux
+	mux    *chi.Mux
+	config xhttp.Config
 }
 
 // NewController creates a new Chi controller.
-func NewController() *Controller {
+// The config applies to every registered route and answers unmatched
+// requests.
+func NewController(config xhttp.Config) *Controller {
+	mux := chi.NewMux()
+	mux.NotFound(config.NotFound().ServeHTTP)
+	mux.MethodNotAllowed(config.MethodNotAllowed().ServeHTTP)
 	return &Controller{
-		mux: chi.NewMux(),
+		mux:    mux,
+		config: config,
 	}
 }
 
 // Handle implements xmux.Controller interface.
 func (c *Controller) Handle(method, path string, api xmux.Api, opts ...map[string]string) {
-	c.mux.Method(method, path, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		// Create bind function to parse request
-		bind := func(ptr any) error {
-			if req.Body == nil {
-				return nil
+	handler := c.config.NewHandler(method, path, api, opts...)
+	// chi names the catch-all segment "*"
+	wildcard := xhttp.WildcardName(path)
+	c.mux.Method(method, xhttp.BracePattern(strings.TrimSuffix(path, wildcard)), http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// Collect path parameters matched by chi
+		urlParams := chi.RouteContext(req.Context()).URLParams
+		params := make(map[string]string, len(urlParams.Keys))
+		for i, key := range urlParams.Keys {
+			if key == "*" && wildcard != "" {
+				key = wildcard
 			}
-			return json.NewDecoder(req.Body).Decode(ptr)
+			params[key] = urlParams.Values[i]
 		}
 
-		// Execute business logic
-		result, err := api.Invoke(req.Context(), bind)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-
-		// Send response
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(result)
+		// Bind, execute business logic and send response
+		handler.ServeHTTP(w, xhttp.WithPathParams(req, params))
 	}))
 }
 
@@ -8,6 +8,8 @@ require (
 	github.com/go-chi/chi/v5 v5.0.11
 )
 
+require golang.org/x/time v0.5.0 // indirect
+
 replace github.com/Just-maple/xmux => ../../
 
 replace github.com/Just-maple/xmux/examples/common => ../common
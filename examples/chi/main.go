@@ -1,15 +1,17 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 
 	"github.com/Just-maple/xmux"
 	"github.com/Just-maple/xmux/examples/common/business"
+	"github.com/Just-maple/xmux/xhttp"
 )
 
 func main() {
-	controller := NewController()
+	controller := NewController(xhttp.Config{})
 	userService := business.NewUserService()
 
 	userGroup := xmux.ServiceGroup(func(r xmux.Router, svc *business.UserService) {
@@ -32,5 +34,7 @@ func main() {
 	}
 
 	log.Println("Chi server starting on :8080")
-	log.Fatal(http.ListenAndServe(":8080", controller))
+	if err := xhttp.RunWithGracefulShutdown(context.Background(), ":8080", controller); err != nil {
+		log.Fatal(err)
+	}
 }
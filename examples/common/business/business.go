@@ -21,7 +21,7 @@ type UserResponse struct {
 }
 
 type GetUserRequest struct {
-	ID string `json:"id"`
+	ID string `json:"id" query:"id"`
 }
 
 type UpdateUserRequest struct {
@@ -30,7 +30,7 @@ type UpdateUserRequest struct {
 }
 
 type DeleteUserRequest struct {
-	ID string `json:"id"`
+	ID string `json:"id" query:"id"`
 }
 
 type DeleteUserResponse struct {
@@ -107,8 +107,8 @@ func (s *UserService) DeleteUser(ctx context.Context, req *DeleteUserRequest) (*
 }
 
 type ListUsersRequest struct {
-	Limit  int `json:"limit"`
-	Offset int `json:"offset"`
+	Limit  int `json:"limit" query:"limit"`
+	Offset int `json:"offset" query:"offset"`
 }
 
 type ListUsersResponse struct {
@@ -2,40 +2,62 @@ package main
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 
 	"github.com/Just-maple/xmux"
+	"github.com/Just-maple/xmux/xhttp"
 )
 
 // Controller adapts Echo to xmux.Controller interface.
 type Controller struct {
 	engine *echo.Echo
+	config xhttp.Config
 }
 
 // NewController creates a new Echo controller.
-func NewController() *Controller {
+// The config applies to every registered route and answers unmatched
+// requests.
+func NewController(config xhttp.Config) *Controller {
+	engine := echo.New()
+	notFound, methodNotAllowed := config.NotFound(), config.MethodNotAllowed()
+	engine.HTTPErrorHandler = func(err error, ctx echo.Context) {
+		// Echo reports unmatched requests as errors of the router
+		switch err {
+		case echo.ErrNotFound:
+			notFound.ServeHTTP(ctx.Response(), ctx.Request())
+		case echo.ErrMethodNotAllowed:
+			methodNotAllowed.ServeHTTP(ctx.Response(), ctx.Request())
+		default:
+			engine.DefaultHTTPErrorHandler(err, ctx)
+		}
+	}
 	return &Controller{
-		engine: echo.New(),
+		engine: engine,
+		config: config,
 	}
 }
 
 // Handle implements xmux.Controller interface.
 func (c *Controller) Handle(method, path string, api xmux.Api, opts ...map[string]string) {
-	c.engine.Add(method, path, func(ctx echo.Context) error {
-		// Create bind function to parse request
-		bind := func(ptr any) error {
-			return ctx.Bind(ptr)
-		}
-
-		// Execute business logic
-		result, err := api.Invoke(ctx.Request().Context(), bind)
-		if err != nil {
-			return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	handler := c.config.NewHandler(method, path, api, opts...)
+	// echo names the catch-all segment "*"
+	wildcard := xhttp.WildcardName(path)
+	c.engine.Add(method, strings.TrimSuffix(path, wildcard), func(ctx echo.Context) error {
+		// Collect path parameters matched by echo
+		names, values := ctx.ParamNames(), ctx.ParamValues()
+		params := make(map[string]string, len(names))
+		for i, name := range names {
+			if name == "*" && wildcard != "" {
+				name = wildcard
+			}
+			params[name] = values[i]
 		}
 
-		// Send response
-		return ctx.JSON(http.StatusOK, result)
+		// Bind, execute business logic and send response
+		handler.ServeHTTP(ctx.Response(), xhttp.WithPathParams(ctx.Request(), params))
+		return nil
 	})
 }
 
@@ -1,46 +1,126 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/Just-maple/xmux"
+	"github.com/Just-maple/xmux/xhttp"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 )
 
 // Controller adapts Fiber to xmux.Controller interface.
 type Controller struct {
-	app *fiber.App
+	app    *fiber.App
+	config xhttp.Config
 }
 
 // NewController creates a new Fiber controller.
-func NewController() *Controller {
+// The config applies to every registered route and answers unmatched
+// requests.
+func NewController(config xhttp.Config) *Controller {
+	notFound := adaptor.HTTPHandler(config.NotFound())
+	methodNotAllowed := adaptor.HTTPHandler(config.MethodNotAllowed())
+	app := fiber.New(fiber.Config{
+		// Fiber reports unmatched requests as errors of the router
+		ErrorHandler: func(ctx *fiber.Ctx, err error) error {
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				switch fiberErr.Code {
+				case fiber.StatusNotFound:
+					return notFound(ctx)
+				case fiber.StatusMethodNotAllowed:
+					return methodNotAllowed(ctx)
+				}
+			}
+			return fiber.DefaultErrorHandler(ctx, err)
+		},
+	})
 	return &Controller{
-		app: fiber.New(),
+		app:    app,
+		config: config,
 	}
 }
 
 // Handle implements xmux.Controller interface.
 func (c *Controller) Handle(method, path string, api xmux.Api, opts ...map[string]string) {
-	c.app.Add(method, path, func(ctx *fiber.Ctx) error {
-		// Create bind function to parse request
-		bind := func(ptr any) error {
-			return ctx.BodyParser(ptr)
+	handler := c.config.NewHandler(method, path, api, opts...)
+	// fiber names the catch-all segment "*1"
+	wildcard := xhttp.WildcardName(path)
+	c.app.Add(method, strings.TrimSuffix(path, wildcard), func(ctx *fiber.Ctx) error {
+		// Collect path parameters matched by fiber
+		params := ctx.AllParams()
+		if wildcard != "" {
+			params[wildcard] = ctx.Params("*")
+			delete(params, "*1")
 		}
 
-		// Execute business logic
-		result, err := api.Invoke(ctx.Context(), bind)
-		if err != nil {
-			return ctx.Status(http.StatusBadRequest).JSON(map[string]string{"error": err.Error()})
-		}
+		userCtx := ctx.UserContext()
 
-		// Send response
-		return ctx.JSON(result)
+		// Bind, execute business logic and send response
+		return adaptor.HTTPHandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			reqCtx, cancel := bridgeContext(req.Context(), userCtx)
+			defer cancel()
+			handler.ServeHTTP(w, xhttp.WithPathParams(req.WithContext(reqCtx), params))
+		})(ctx)
 	})
 }
 
+// bridgeContext returns a context derived from the request context ctx
+// that also carries the values of the fiber user context, so values set
+// by fiber middleware via SetUserContext reach the handler. The deadline
+// and cancellation of the user context apply as well.
+func bridgeContext(ctx context.Context, user context.Context) (context.Context, context.CancelFunc) {
+	ctx = valuesContext{Context: ctx, values: user}
+	var cancel context.CancelFunc
+	if deadline, ok := user.Deadline(); ok {
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	if done := user.Done(); done != nil {
+		go func() {
+			select {
+			case <-done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return ctx, cancel
+}
+
+// valuesContext is a context looking up values in values first.
+type valuesContext struct {
+	context.Context
+	values context.Context
+}
+
+// Value implements context.Context.
+func (c valuesContext) Value(key any) any {
+	if v := c.values.Value(key); v != nil {
+		return v
+	}
+	return c.Context.Value(key)
+}
+
 // ServeHTTP implements http.Handler interface.
 func (c *Controller) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	// Fiber doesn't directly support http.Handler interface
-	// This is a simplified implementation for testing
-	c.app.Test(req, -1)
+	// Fiber doesn't directly support http.Handler interface, so the
+	// request is served in memory and the response copied to w
+	resp, err := c.app.Test(req, -1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer resp.Body.Close()
+	for key, values := range resp.Header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
 }
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Just-maple/xmux"
+	"github.com/Just-maple/xmux/xhttp"
+	"github.com/Just-maple/xmux/xmuxtest"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestConformance(t *testing.T) {
+	xmuxtest.RunConformance(t, func() xmuxtest.Adapter {
+		return NewController(xhttp.Config{})
+	})
+}
+
+type noParams struct{}
+
+func TestRequestTimeout(t *testing.T) {
+	c := NewController(xhttp.Config{RequestTimeout: 10 * time.Millisecond})
+	c.Handle(http.MethodGet, "/timeout", xmux.NewHandler(func(ctx context.Context, params *noParams) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}))
+	c.Handle(http.MethodGet, "/late", xmux.NewHandler(func(ctx context.Context, params *noParams) (string, error) {
+		<-ctx.Done()
+		return "done", nil
+	}))
+
+	for target, want := range map[string]int{"/timeout": http.StatusGatewayTimeout, "/late": http.StatusOK} {
+		rec := httptest.NewRecorder()
+		c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != want {
+			t.Errorf("%s: status %d, want %d: %s", target, rec.Code, want, rec.Body)
+		}
+	}
+}
+
+type userKey struct{}
+
+func TestUserContextValues(t *testing.T) {
+	c := NewController(xhttp.Config{})
+	c.app.Use(func(ctx *fiber.Ctx) error {
+		ctx.SetUserContext(context.WithValue(ctx.UserContext(), userKey{}, "alice"))
+		return ctx.Next()
+	})
+	c.Handle(http.MethodGet, "/user", xmux.NewHandler(func(ctx context.Context, params *noParams) (string, error) {
+		user, _ := ctx.Value(userKey{}).(string)
+		if _, ok := xmux.RequestInfoFromContext(ctx); !ok {
+			return "", errors.New("request info missing")
+		}
+		return user, nil
+	}))
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/user", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "alice" {
+		t.Errorf("status %d, body %q, want the value set by the middleware", rec.Code, rec.Body)
+	}
+}
+
+func TestBridgeContext(t *testing.T) {
+	req, cancelReq := context.WithCancel(context.Background())
+	ctx, cancel := bridgeContext(req, context.WithValue(context.Background(), userKey{}, "alice"))
+	defer cancel()
+	cancelReq()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("request cancellation not propagated")
+	}
+
+	user, cancelUser := context.WithTimeout(context.Background(), time.Hour)
+	ctx, cancel = bridgeContext(context.Background(), user)
+	defer cancel()
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("user context deadline not applied")
+	}
+	cancelUser()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("user context cancellation not propagated")
+	}
+}
@@ -3,13 +3,15 @@ package main
 import (
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/Just-maple/xmux"
 	"github.com/Just-maple/xmux/examples/common/business"
+	"github.com/Just-maple/xmux/xhttp"
 )
 
 func main() {
-	controller := NewController()
+	controller := NewController(xhttp.Config{RequestTimeout: 10 * time.Second})
 	userService := business.NewUserService()
 
 	userGroup := xmux.ServiceGroup(func(r xmux.Router, svc *business.UserService) {
@@ -2,43 +2,50 @@ package main
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/Just-maple/xmux"
+	"github.com/Just-maple/xmux/xhttp"
 	"github.com/gin-gonic/gin"
 )
 
 // Controller adapts Gin to xmux.Controller interface.
 type Controller struct {
 	engine *gin.Engine
+	config xhttp.Config
 }
 
 // NewController creates a new Gin controller.
-func NewController() *Controller {
+// The config applies to every registered route and answers unmatched
+// requests.
+func NewController(config xhttp.Config) *Controller {
+	engine := gin.Default()
+	engine.HandleMethodNotAllowed = true
+	engine.NoRoute(gin.WrapH(config.NotFound()))
+	engine.NoMethod(gin.WrapH(config.MethodNotAllowed()))
 	return &Controller{
-		engine: gin.Default(),
+		engine: engine,
+		config: config,
 	}
 }
 
 // Handle implements xmux.Controller interface.
 func (c *Controller) Handle(method, path string, api xmux.Api, opts ...map[string]string) {
+	handler := c.config.NewHandler(method, path, api, opts...)
+	// gin captures the catch-all segment with a leading slash
+	wildcard := xhttp.WildcardName(path)
 	c.engine.Handle(method, path, func(ctx *gin.Context) {
-		// Create bind function to parse request
-		bind := func(ptr any) error {
-			if err := ctx.ShouldBindJSON(ptr); err != nil {
-				return ctx.ShouldBindQuery(ptr)
+		// Collect path parameters matched by gin
+		params := make(map[string]string, len(ctx.Params))
+		for _, param := range ctx.Params {
+			if param.Key == wildcard {
+				param.Value = strings.TrimPrefix(param.Value, "/")
 			}
-			return nil
+			params[param.Key] = param.Value
 		}
 
-		// Execute business logic
-		result, err := api.Invoke(ctx.Request.Context(), bind)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-
-		// Send response
-		ctx.JSON(http.StatusOK, result)
+		// Bind, execute business logic and send response
+		handler.ServeHTTP(ctx.Writer, xhttp.WithPathParams(ctx.Request, params))
 	})
 }
 
@@ -1,16 +1,21 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/Just-maple/xmux"
 	"github.com/Just-maple/xmux/examples/common/business"
+	"github.com/Just-maple/xmux/metrics"
+	"github.com/Just-maple/xmux/xhttp"
 )
 
 func main() {
-	controller := NewController()
+	controller := NewController(xhttp.Config{})
 	userService := business.NewUserService()
+	requestMetrics := metrics.New()
 
 	userGroup := xmux.ServiceGroup(func(r xmux.Router, svc *business.UserService) {
 		xmux.Register(r, http.MethodPost, "/users", svc.CreateUser)
@@ -18,9 +23,10 @@ func main() {
 		xmux.Register(r, http.MethodGet, "/user", svc.GetUser)
 		xmux.Register(r, http.MethodPut, "/users", svc.UpdateUser)
 		xmux.Register(r, http.MethodDelete, "/users", svc.DeleteUser)
+		xmux.Register(r, http.MethodGet, "/notifications", Notifications)
 	})
 
-	err := userGroup.Bind(controller, func(ptr any) error {
+	err := requestMetrics.Instrument(userGroup).Bind(controller, func(ptr any) error {
 		switch p := ptr.(type) {
 		case **business.UserService:
 			*p = userService
@@ -31,6 +37,49 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// Expose metrics next to the instrumented routes
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", requestMetrics.Handler())
+	mux.Handle("/", controller)
+
 	log.Println("Gin server starting on :8080")
-	log.Fatal(http.ListenAndServe(":8080", controller))
+	if err := xhttp.RunWithGracefulShutdown(context.Background(), ":8080", mux); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// NotificationsRequest selects how many notifications to stream.
+type NotificationsRequest struct {
+	Count int `query:"count"`
+}
+
+// Notifications streams a notification per second as Server-Sent Events.
+func Notifications(ctx context.Context, req *NotificationsRequest) (*xmux.SSEResponse, error) {
+	count := req.Count
+	if count <= 0 {
+		count = 5
+	}
+	events := make(chan xmux.Event)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for i := 1; i <= count; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				event := xmux.Event{
+					Event: "notification",
+					Data:  map[string]any{"seq": i, "at": now},
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return &xmux.SSEResponse{Events: events}, nil
 }
@@ -1,52 +1,38 @@
 package main
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/Just-maple/xmux"
+	"github.com/Just-maple/xmux/xhttp"
 	"github.com/gorilla/mux"
 )
 
 // Controller adapts Gorilla/mux to xmux.Controller interface.
 type Controller struct {
-	mux *mux.Router
+	mux    *mux.Router
+	config xhttp.Config
 }
 
 // NewController creates a new Gorilla/mux controller.
-func NewController() *Controller {
+// The config applies to every registered route and answers unmatched
+// requests.
+func NewController(config xhttp.Config) *Controller {
+	router := mux.NewRouter()
+	router.NotFoundHandler = config.NotFound()
+	router.MethodNotAllowedHandler = config.MethodNotAllowed()
 	return &Controller{
-		mux: mux.NewRouter(),
+		mux:    router,
+		config: config,
 	}
 }
 
 // Handle implements xmux.Controller interface.
 func (c *Controller) Handle(method, path string, api xmux.Api, opts ...map[string]string) {
-	c.mux.HandleFunc(path, func(w http.ResponseWriter, req *http.Request) {
-		// Check HTTP method
-		if req.Method != method {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		// Create bind function to parse request
-		bind := func(ptr any) error {
-			if req.Body == nil {
-				return nil
-			}
-			return json.NewDecoder(req.Body).Decode(ptr)
-		}
-
-		// Execute business logic
-		result, err := api.Invoke(req.Context(), bind)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-
-		// Send response
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(result)
+	handler := c.config.NewHandler(method, path, api, opts...)
+	c.mux.HandleFunc(xhttp.BracePattern(path), func(w http.ResponseWriter, req *http.Request) {
+		// Bind, execute business logic and send response
+		handler.ServeHTTP(w, xhttp.WithPathParams(req, mux.Vars(req)))
 	}).Methods(method)
 }
 
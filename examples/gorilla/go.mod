@@ -8,6 +8,8 @@ require (
 	github.com/gorilla/mux v1.8.1
 )
 
+require golang.org/x/time v0.5.0 // indirect
+
 replace github.com/Just-maple/xmux => ../../
 
 replace github.com/Just-maple/xmux/examples/common => ../common
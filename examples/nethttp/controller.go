@@ -1,55 +1,88 @@
 package main
 
 import (
-	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/Just-maple/xmux"
+	"github.com/Just-maple/xmux/xhttp"
 )
 
 // Controller adapts net/http.ServeMux to xmux.Controller interface.
 type Controller struct {
-	mux *http.ServeMux
+	mux    *http.ServeMux
+	config xhttp.Config
+
+	// routes holds the handlers of each pattern by method, as ServeMux
+	// takes a single handler per pattern
+	routes map[string]map[string]http.Handler
 }
 
 // NewController creates a new net/http controller.
-func NewController() *Controller {
+// The config applies to every registered route and answers unmatched
+// requests.
+func NewController(config xhttp.Config) *Controller {
 	return &Controller{
-		mux: http.NewServeMux(),
+		mux:    http.NewServeMux(),
+		config: config,
+		routes: make(map[string]map[string]http.Handler),
 	}
 }
 
 // Handle implements xmux.Controller interface.
 func (c *Controller) Handle(method, path string, api xmux.Api, opts ...map[string]string) {
-	c.mux.HandleFunc(path, func(w http.ResponseWriter, req *http.Request) {
+	handler := c.config.NewHandler(method, path, api, opts...)
+	pattern, names := servePattern(path)
+	if methods, ok := c.routes[pattern]; ok {
+		methods[method] = handler
+		return
+	}
+	methods := map[string]http.Handler{method: handler}
+	c.routes[pattern] = methods
+	c.mux.HandleFunc(pattern, func(w http.ResponseWriter, req *http.Request) {
 		// Check HTTP method
-		if req.Method != method {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		handler, ok := methods[req.Method]
+		if !ok {
+			c.config.MethodNotAllowed().ServeHTTP(w, req)
 			return
 		}
 
-		// Create bind function to parse request
-		bind := func(ptr any) error {
-			if req.Body == nil {
-				return nil
-			}
-			return json.NewDecoder(req.Body).Decode(ptr)
-		}
-
-		// Execute business logic
-		result, err := api.Invoke(req.Context(), bind)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
+		// Collect path parameters matched by ServeMux
+		params := make(map[string]string, len(names))
+		for _, name := range names {
+			params[name] = req.PathValue(name)
 		}
 
-		// Send response
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(result)
+		// Bind, execute business logic and send response
+		handler.ServeHTTP(w, xhttp.WithPathParams(req, params))
 	})
 }
 
+// servePattern converts an xmux route pattern to a ServeMux pattern,
+// ":name" segments becoming "{name}" and a catch-all "*name" segment
+// "{name...}", and returns the names of its path parameters.
+func servePattern(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var names []string
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			names = append(names, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		case strings.HasPrefix(segment, "*") && len(segment) > 1:
+			names = append(names, segment[1:])
+			segments[i] = "{" + segment[1:] + "...}"
+		}
+	}
+	return strings.Join(segments, "/"), names
+}
+
 // ServeHTTP implements http.Handler interface.
 func (c *Controller) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// ServeMux has no hook for unmatched requests
+	if _, pattern := c.mux.Handler(req); pattern == "" {
+		c.config.NotFound().ServeHTTP(w, req)
+		return
+	}
 	c.mux.ServeHTTP(w, req)
 }
@@ -1,12 +1,14 @@
 module github.com/Just-maple/xmux/examples/nethttp
 
-go 1.18
+go 1.22
 
 require (
 	github.com/Just-maple/xmux v1.0.0
 	github.com/Just-maple/xmux/examples/common v0.0.0
 )
 
+require golang.org/x/time v0.5.0 // indirect
+
 replace github.com/Just-maple/xmux => ../../
 
 replace github.com/Just-maple/xmux/examples/common => ../common
@@ -2,15 +2,22 @@ package main
 
 import (
 	"github.com/Just-maple/xmux/examples/webapp/pkg/server"
+	"github.com/Just-maple/xmux/xhttp"
 	"log"
 	"time"
 )
 
 func main() {
 	cfg := server.ServerConfig{
-		Addr:         ":8080",
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		Addr:           ":8080",
+		ReadTimeout:    15 * time.Second,
+		WriteTimeout:   15 * time.Second,
+		RequestTimeout: 10 * time.Second,
+		CORS: xhttp.CORSOptions{
+			AllowedOrigins: []string{"http://localhost:3000"},
+			AllowedHeaders: []string{"Authorization", "Content-Type"},
+			MaxAge:         10 * time.Minute,
+		},
 	}
 
 	srv, err := server.NewServer(cfg)
@@ -6,6 +6,7 @@ require (
 	github.com/Just-maple/godi v0.0.0-20260304015920-020362515ad7
 	github.com/Just-maple/xmux v1.0.0
 	github.com/gin-gonic/gin v1.12.0
+	golang.org/x/crypto v0.48.0
 )
 
 require (
@@ -33,10 +34,10 @@ require (
 	github.com/ugorji/go/codec v1.3.1 // indirect
 	go.mongodb.org/mongo-driver/v2 v2.5.0 // indirect
 	golang.org/x/arch v0.22.0 // indirect
-	golang.org/x/crypto v0.48.0 // indirect
 	golang.org/x/net v0.51.0 // indirect
 	golang.org/x/sys v0.41.0 // indirect
 	golang.org/x/text v0.34.0 // indirect
+	golang.org/x/time v0.5.0 // indirect
 	google.golang.org/protobuf v1.36.10 // indirect
 )
 
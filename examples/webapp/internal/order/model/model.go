@@ -23,7 +23,7 @@ type CreateOrderRequest struct {
 }
 
 type GetOrderRequest struct {
-	ID string `json:"id"`
+	ID string `json:"-" path:"id"`
 }
 
 type OrderResponse struct {
@@ -2,11 +2,14 @@ package repository
 
 import (
 	"context"
-	"fmt"
+	"github.com/Just-maple/xmux"
 	"github.com/Just-maple/xmux/examples/webapp/internal/order/model"
+	"net/http"
 	"time"
 )
 
+var ErrOrderNotFound = xmux.NewDomainError("order_not_found", http.StatusNotFound, "order not found")
+
 type OrderRepository interface {
 	Create(ctx context.Context, order *model.Order) error
 	GetByID(ctx context.Context, id string) (*model.Order, error)
@@ -32,7 +35,7 @@ func (r *orderRepository) Create(ctx context.Context, order *model.Order) error
 func (r *orderRepository) GetByID(ctx context.Context, id string) (*model.Order, error) {
 	order, exists := r.orders[id]
 	if !exists {
-		return nil, fmt.Errorf("order not found")
+		return nil, ErrOrderNotFound
 	}
 	return order, nil
 }
@@ -50,7 +53,7 @@ func (r *orderRepository) GetByUserID(ctx context.Context, userID string) ([]*mo
 func (r *orderRepository) UpdateStatus(ctx context.Context, id string, status string) error {
 	order, exists := r.orders[id]
 	if !exists {
-		return fmt.Errorf("order not found")
+		return ErrOrderNotFound
 	}
 	order.Status = status
 	order.CreatedAt = time.Now()
@@ -32,7 +32,7 @@ func (s *OrderService) CreateOrder(ctx context.Context, req *model.CreateOrderRe
 	for _, item := range req.Items {
 		product, err := s.productRepo.GetByID(ctx, item.ProductID)
 		if err != nil {
-			return nil, fmt.Errorf("product %s not found", item.ProductID)
+			return nil, fmt.Errorf("product %s: %w", item.ProductID, err)
 		}
 
 		if product.Stock < item.Quantity {
@@ -15,11 +15,11 @@ type CreateProductRequest struct {
 }
 
 type GetProductRequest struct {
-	ID string `json:"id"`
+	ID string `json:"-" path:"id"`
 }
 
 type UpdateProductRequest struct {
-	ID          string  `json:"id"`
+	ID          string  `json:"-" path:"id"`
 	Name        string  `json:"name"`
 	Description string  `json:"description"`
 	Price       float64 `json:"price"`
@@ -27,7 +27,7 @@ type UpdateProductRequest struct {
 }
 
 type DeleteProductRequest struct {
-	ID string `json:"id"`
+	ID string `json:"-" path:"id"`
 }
 
 type ProductResponse struct {
@@ -2,8 +2,14 @@ package repository
 
 import (
 	"context"
-	"fmt"
+	"github.com/Just-maple/xmux"
 	"github.com/Just-maple/xmux/examples/webapp/internal/product/model"
+	"net/http"
+)
+
+var (
+	ErrProductNotFound      = xmux.NewDomainError("product_not_found", http.StatusNotFound, "product not found")
+	ErrProductAlreadyExists = xmux.NewDomainError("product_already_exists", http.StatusConflict, "product already exists")
 )
 
 type ProductRepository interface {
@@ -26,7 +32,7 @@ func NewProductRepository() ProductRepository {
 
 func (r *productRepository) Create(ctx context.Context, product *model.Product) error {
 	if _, exists := r.products[product.ID]; exists {
-		return fmt.Errorf("product already exists")
+		return ErrProductAlreadyExists
 	}
 	r.products[product.ID] = product
 	return nil
@@ -35,7 +41,7 @@ func (r *productRepository) Create(ctx context.Context, product *model.Product)
 func (r *productRepository) GetByID(ctx context.Context, id string) (*model.Product, error) {
 	product, exists := r.products[id]
 	if !exists {
-		return nil, fmt.Errorf("product not found")
+		return nil, ErrProductNotFound
 	}
 	return product, nil
 }
@@ -50,7 +56,7 @@ func (r *productRepository) GetAll(ctx context.Context) ([]*model.Product, error
 
 func (r *productRepository) Update(ctx context.Context, product *model.Product) error {
 	if _, exists := r.products[product.ID]; !exists {
-		return fmt.Errorf("product not found")
+		return ErrProductNotFound
 	}
 	r.products[product.ID] = product
 	return nil
@@ -58,7 +64,7 @@ func (r *productRepository) Update(ctx context.Context, product *model.Product)
 
 func (r *productRepository) Delete(ctx context.Context, id string) error {
 	if _, exists := r.products[id]; !exists {
-		return fmt.Errorf("product not found")
+		return ErrProductNotFound
 	}
 	delete(r.products, id)
 	return nil
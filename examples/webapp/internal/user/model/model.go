@@ -1,33 +1,137 @@
 package model
 
+import (
+	"time"
+
+	"github.com/Just-maple/xmux"
+)
+
 type User struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"-"`
+	ID        string     `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Email     string     `json:"email" db:"email"`
+	Password  string     `json:"-" db:"password"`
+	Role      string     `json:"role" db:"role"`
+	Verified  bool       `json:"verified" db:"verified"`
+	Version   int        `json:"version" db:"version"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
 type CreateUserRequest struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" sensitive:"true" validate:"required"`
+}
+
+// BatchCreateUsersRequest takes the users as a JSON array body, each
+// validated on its own.
+type BatchCreateUsersRequest struct {
+	Users []*CreateUserRequest `in:"body"`
+	// AllOrNothing creates no user if any of them fails
+	AllOrNothing bool `json:"-" query:"all_or_nothing"`
+}
+
+// BatchItem reports the outcome of one user of a batch, by its index
+// in the request.
+type BatchItem struct {
+	Index int           `json:"index"`
+	User  *UserResponse `json:"user,omitempty"`
+	Error string        `json:"error,omitempty"`
+	Code  string        `json:"code,omitempty"`
+}
+
+type BatchResult struct {
+	Created int         `json:"created"`
+	Failed  int         `json:"failed"`
+	Items   []BatchItem `json:"items"`
+}
+
+type ChangePasswordRequest struct {
+	ID          string `json:"-" path:"id"`
+	OldPassword string `json:"old_password" sensitive:"true" validate:"required"`
+	NewPassword string `json:"new_password" sensitive:"true" validate:"required"`
+}
+
+func (r *ChangePasswordRequest) OwnerID() string { return r.ID }
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// ListUsersRequest pages through the users; xmux.Paginate bounds its
+// limit.
+type ListUsersRequest struct {
+	xmux.PageParams
+}
+
+type ListUsersResponse struct {
+	Users  []*UserResponse `json:"users"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+// Page makes list responses carry X-Total-Count and Link headers.
+func (r *ListUsersResponse) Page() xmux.PageMeta {
+	return xmux.PageMeta{Total: r.Total, Limit: r.Limit, Offset: r.Offset}
 }
 
 type GetUserRequest struct {
-	ID string `json:"id"`
+	ID string `json:"-" path:"id"`
 }
 
+func (r *GetUserRequest) OwnerID() string { return r.ID }
+
 type UpdateUserRequest struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID    string                `json:"-" path:"id"`
+	Name  xmux.Optional[string] `json:"name"`
+	Email xmux.Optional[string] `json:"email"`
 }
 
+func (r *UpdateUserRequest) OwnerID() string { return r.ID }
+
 type DeleteUserRequest struct {
-	ID string `json:"id"`
+	ID string `json:"-" path:"id"`
 }
 
+func (r *DeleteUserRequest) OwnerID() string { return r.ID }
+
 type UserResponse struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email" visible:"admin,self"`
+	Role      string    `json:"role"`
+	Verified  bool      `json:"verified"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OwnerID makes the email of a user visible to the user.
+func (r *UserResponse) OwnerID() string { return r.ID }
+
+// LastModified makes user responses carry a Last-Modified header and
+// answer If-Modified-Since.
+func (r *UserResponse) LastModified() time.Time {
+	return r.UpdatedAt
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" sensitive:"true" validate:"required"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password" sensitive:"true"`
+}
+
+type LoginResponse struct {
+	Token        string `json:"token" sensitive:"true"`
+	RefreshToken string `json:"refresh_token" sensitive:"true"`
+}
+
+type LogoutRequest struct{}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" sensitive:"true" validate:"required"`
 }
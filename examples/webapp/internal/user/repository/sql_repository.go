@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Just-maple/xmux/examples/webapp/internal/user/model"
+)
+
+// Schema creates the users table used by the SQL repository. It is
+// written for SQLite; other databases may need different column types.
+const Schema = `CREATE TABLE IF NOT EXISTS users (
+	id         TEXT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	email      TEXT NOT NULL,
+	email_key  TEXT NOT NULL UNIQUE,
+	password   TEXT NOT NULL,
+	role       TEXT NOT NULL,
+	verified   BOOLEAN NOT NULL DEFAULT FALSE,
+	version    INTEGER NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	deleted_at TIMESTAMP NULL
+)`
+
+const userColumns = "id, name, email, password, role, verified, version, updated_at, deleted_at"
+
+// New returns the user repository for driver: the in-memory one for
+// "memory" or an empty driver, otherwise a SQL repository on the
+// database opened with dsn. SQL drivers must be registered by importing
+// them, e.g. _ "modernc.org/sqlite", and the users table is created if
+// missing.
+func New(driver string, dsn string) (UserRepository, error) {
+	if driver == "" || driver == "memory" {
+		return NewUserRepository(), nil
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(Schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return NewSQLUserRepository(db), nil
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// sqlUserRepository stores users in a database/sql database. Queries
+// use ? placeholders, as SQLite and MySQL do.
+type sqlUserRepository struct {
+	db *sql.DB
+}
+
+type sqlTxKey struct{}
+
+func NewSQLUserRepository(db *sql.DB) UserRepository {
+	return &sqlUserRepository{db: db}
+}
+
+// q returns the transaction of ctx, if any, or the database.
+func (r *sqlUserRepository) q(ctx context.Context) querier {
+	if tx, ok := ctx.Value(sqlTxKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return r.db
+}
+
+func (r *sqlUserRepository) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(sqlTxKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(context.WithValue(ctx, sqlTxKey{}, tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *sqlUserRepository) Create(ctx context.Context, user *model.User) error {
+	return r.WithinTx(ctx, func(ctx context.Context) error {
+		return r.insert(ctx, user)
+	})
+}
+
+func (r *sqlUserRepository) insert(ctx context.Context, user *model.User) error {
+	var count int
+	err := r.q(ctx).QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM users WHERE id = ? OR email_key = ?", user.ID, emailKey(user.Email),
+	).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrUserAlreadyExists
+	}
+	now := time.Now()
+	_, err = r.q(ctx).ExecContext(ctx,
+		"INSERT INTO users (id, name, email, email_key, password, role, verified, version, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, 1, ?)",
+		user.ID, user.Name, user.Email, emailKey(user.Email), user.Password, user.Role, user.Verified, now,
+	)
+	if err != nil {
+		return err
+	}
+	user.Version, user.UpdatedAt = 1, now
+	return nil
+}
+
+func (r *sqlUserRepository) CreateBatch(ctx context.Context, users []*model.User, mode BatchMode) ([]error, error) {
+	errs := make([]error, len(users))
+	err := r.WithinTx(ctx, func(ctx context.Context) error {
+		failed := false
+		for i, user := range users {
+			if err := r.insert(ctx, user); err != nil {
+				if !errors.Is(err, ErrUserAlreadyExists) {
+					return err
+				}
+				errs[i] = err
+				failed = true
+			}
+		}
+		if failed && mode == AllOrNothing {
+			for i := range errs {
+				if errs[i] == nil {
+					errs[i] = ErrBatchAborted
+				}
+			}
+			return ErrBatchAborted
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrBatchAborted) {
+		return nil, err
+	}
+	return errs, nil
+}
+
+func (r *sqlUserRepository) GetByID(ctx context.Context, id string, opts ...GetOption) (*model.User, error) {
+	var options getOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	query := "SELECT " + userColumns + " FROM users WHERE id = ?"
+	if !options.includeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+	return scanUser(r.q(ctx).QueryRowContext(ctx, query, id))
+}
+
+func (r *sqlUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	return scanUser(r.q(ctx).QueryRowContext(ctx,
+		"SELECT "+userColumns+" FROM users WHERE email_key = ? AND deleted_at IS NULL", emailKey(email),
+	))
+}
+
+func (r *sqlUserRepository) List(ctx context.Context, offset, limit int) ([]*model.User, int, error) {
+	var total int
+	if err := r.q(ctx).QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE deleted_at IS NULL").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.q(ctx).QueryContext(ctx,
+		"SELECT "+userColumns+" FROM users WHERE deleted_at IS NULL ORDER BY id LIMIT ? OFFSET ?", limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var users []*model.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	return users, total, rows.Err()
+}
+
+func (r *sqlUserRepository) Update(ctx context.Context, user *model.User) error {
+	return r.WithinTx(ctx, func(ctx context.Context) error {
+		var count int
+		err := r.q(ctx).QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM users WHERE email_key = ? AND id <> ?", emailKey(user.Email), user.ID,
+		).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrUserAlreadyExists
+		}
+		now := time.Now()
+		result, err := r.q(ctx).ExecContext(ctx,
+			"UPDATE users SET name = ?, email = ?, email_key = ?, password = ?, role = ?, verified = ?, version = version + 1, updated_at = ? WHERE id = ? AND version = ? AND deleted_at IS NULL",
+			user.Name, user.Email, emailKey(user.Email), user.Password, user.Role, user.Verified, now, user.ID, user.Version,
+		)
+		if err != nil {
+			return err
+		}
+		if err := r.checkAffected(ctx, result, user.ID); err != nil {
+			return err
+		}
+		user.Version++
+		user.UpdatedAt = now
+		return nil
+	})
+}
+
+func (r *sqlUserRepository) Delete(ctx context.Context, id string) error {
+	now := time.Now()
+	result, err := r.q(ctx).ExecContext(ctx,
+		"UPDATE users SET deleted_at = ?, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL", now, now, id,
+	)
+	if err != nil {
+		return err
+	}
+	return affected(result)
+}
+
+func (r *sqlUserRepository) Restore(ctx context.Context, id string) error {
+	result, err := r.q(ctx).ExecContext(ctx,
+		"UPDATE users SET deleted_at = NULL, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL", time.Now(), id,
+	)
+	if err != nil {
+		return err
+	}
+	return affected(result)
+}
+
+func (r *sqlUserRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+func (r *sqlUserRepository) Close() error {
+	return r.db.Close()
+}
+
+// checkAffected tells an update of a missing user from one of a user
+// modified since it was read.
+func (r *sqlUserRepository) checkAffected(ctx context.Context, result sql.Result, id string) error {
+	if err := affected(result); err != ErrUserNotFound {
+		return err
+	}
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return ErrConflict
+}
+
+// affected returns ErrUserNotFound if result affected no row.
+func affected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// scanUser scans a row of userColumns, from a *sql.Row or *sql.Rows.
+func scanUser(row interface{ Scan(dest ...any) error }) (*model.User, error) {
+	var user model.User
+	var deletedAt sql.NullTime
+	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.Verified, &user.Version, &user.UpdatedAt, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+	return &user, nil
+}
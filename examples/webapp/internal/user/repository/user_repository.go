@@ -3,64 +3,331 @@ package repository
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Just-maple/xmux"
 	"github.com/Just-maple/xmux/examples/webapp/internal/user/model"
 )
 
+var (
+	ErrUserNotFound      = xmux.NewDomainError("user_not_found", http.StatusNotFound, "user not found")
+	ErrUserAlreadyExists = xmux.NewDomainError("user_already_exists", http.StatusConflict, "user already exists")
+	// ErrConflict is returned by Update when the user was modified since
+	// it was read
+	ErrConflict = xmux.NewDomainError("user_conflict", http.StatusConflict, "user was modified concurrently")
+	// ErrBatchAborted is reported by CreateBatch in AllOrNothing mode for
+	// users not created because another user of the batch failed
+	ErrBatchAborted = xmux.NewDomainError("batch_aborted", http.StatusConflict, "not created because another user in the batch failed")
+)
+
+// BatchMode selects how CreateBatch handles users that cannot be created.
+type BatchMode int
+
+const (
+	// BestEffort creates every user it can and reports the others
+	BestEffort BatchMode = iota
+	// AllOrNothing creates no user if any of them cannot be created
+	AllOrNothing
+)
+
+// GetOption customizes GetByID.
+type GetOption func(*getOptions)
+
+type getOptions struct {
+	includeDeleted bool
+}
+
+// IncludeDeleted makes GetByID return soft deleted users too.
+func IncludeDeleted() GetOption {
+	return func(o *getOptions) {
+		o.includeDeleted = true
+	}
+}
+
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
-	GetByID(ctx context.Context, id string) (*model.User, error)
+	// CreateBatch creates users and returns one error per user, nil for
+	// those created. The second return value reports failures of the
+	// batch as a whole.
+	CreateBatch(ctx context.Context, users []*model.User, mode BatchMode) ([]error, error)
+	// GetByID returns a user that is not soft deleted, unless
+	// IncludeDeleted is given
+	GetByID(ctx context.Context, id string, opts ...GetOption) (*model.User, error)
+	// GetByEmail never returns soft deleted users, so they cannot log in
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	// List returns at most limit users that are not soft deleted,
+	// ordered by id and skipping the first offset, and their total count
+	List(ctx context.Context, offset, limit int) ([]*model.User, int, error)
+	// Update replaces the user if its Version matches the stored one,
+	// and increments the version
 	Update(ctx context.Context, user *model.User) error
+	// Delete soft deletes the user by setting DeletedAt
 	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) error
+	Ping(ctx context.Context) error
+	TxManager
+}
+
+// TxManager runs multi-step operations atomically.
+type TxManager interface {
+	// WithinTx calls fn with a context carrying a transaction. Repository
+	// calls made with that context are committed if fn returns nil and
+	// rolled back otherwise. Nested calls join the outer transaction.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
+// userRepository stores copies of users, so callers cannot modify stored
+// users without Update keeping the indexes consistent.
 type userRepository struct {
+	mu    sync.RWMutex
 	users map[string]*model.User
+	// byEmail indexes user ids by lowercased email, so email lookups and
+	// uniqueness checks are O(1) and case-insensitive
+	byEmail map[string]string
 }
 
 func NewUserRepository() UserRepository {
 	return &userRepository{
-		users: make(map[string]*model.User),
+		users:   make(map[string]*model.User),
+		byEmail: make(map[string]string),
+	}
+}
+
+type txKey struct{}
+
+// WithinTx holds the write lock for the whole transaction, so
+// transactions are serialized, and restores a snapshot of the store if
+// fn fails.
+func (r *userRepository) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.inTx(ctx) {
+		return fn(ctx)
 	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := make(map[string]*model.User, len(r.users))
+	for id, user := range r.users {
+		stored := *user
+		users[id] = &stored
+	}
+	byEmail := make(map[string]string, len(r.byEmail))
+	for email, id := range r.byEmail {
+		byEmail[email] = id
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, r)); err != nil {
+		r.users, r.byEmail = users, byEmail
+		return err
+	}
+	return nil
+}
+
+func (r *userRepository) inTx(ctx context.Context) bool {
+	tx, _ := ctx.Value(txKey{}).(*userRepository)
+	return tx == r
+}
+
+// lock acquires the write lock unless ctx carries a transaction of r,
+// which already holds it, and returns the matching unlock.
+func (r *userRepository) lock(ctx context.Context) func() {
+	if r.inTx(ctx) {
+		return func() {}
+	}
+	r.mu.Lock()
+	return r.mu.Unlock
+}
+
+// rlock is like lock for the read lock.
+func (r *userRepository) rlock(ctx context.Context) func() {
+	if r.inTx(ctx) {
+		return func() {}
+	}
+	r.mu.RLock()
+	return r.mu.RUnlock
+}
+
+func emailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
 func (r *userRepository) Create(ctx context.Context, user *model.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	defer r.lock(ctx)()
 	if _, exists := r.users[user.ID]; exists {
-		return fmt.Errorf("user already exists")
+		return ErrUserAlreadyExists
+	}
+	if _, exists := r.byEmail[emailKey(user.Email)]; exists {
+		return ErrUserAlreadyExists
 	}
-	r.users[user.ID] = user
+	user.Version, user.UpdatedAt = 1, time.Now()
+	stored := *user
+	r.users[user.ID] = &stored
+	r.byEmail[emailKey(user.Email)] = user.ID
 	return nil
 }
 
-func (r *userRepository) GetByID(ctx context.Context, id string) (*model.User, error) {
+func (r *userRepository) CreateBatch(ctx context.Context, users []*model.User, mode BatchMode) ([]error, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	defer r.lock(ctx)()
+
+	errs := make([]error, len(users))
+	ids := make(map[string]bool, len(users))
+	emails := make(map[string]bool, len(users))
+	failed := false
+	for i, user := range users {
+		_, idExists := r.users[user.ID]
+		_, emailExists := r.byEmail[emailKey(user.Email)]
+		if idExists || emailExists || ids[user.ID] || emails[emailKey(user.Email)] {
+			errs[i] = ErrUserAlreadyExists
+			failed = true
+			continue
+		}
+		ids[user.ID] = true
+		emails[emailKey(user.Email)] = true
+	}
+	if failed && mode == AllOrNothing {
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = ErrBatchAborted
+			}
+		}
+		return errs, nil
+	}
+
+	for i, user := range users {
+		if errs[i] != nil {
+			continue
+		}
+		user.Version, user.UpdatedAt = 1, time.Now()
+		stored := *user
+		r.users[user.ID] = &stored
+		r.byEmail[emailKey(user.Email)] = user.ID
+	}
+	return errs, nil
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id string, opts ...GetOption) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var options getOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	defer r.rlock(ctx)()
 	user, exists := r.users[id]
-	if !exists {
-		return nil, fmt.Errorf("user not found")
+	if !exists || user.DeletedAt != nil && !options.includeDeleted {
+		return nil, ErrUserNotFound
 	}
-	return user, nil
+	found := *user
+	return &found, nil
 }
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
-	for _, user := range r.users {
-		if user.Email == email {
-			return user, nil
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	defer r.rlock(ctx)()
+	id, exists := r.byEmail[emailKey(email)]
+	if !exists || r.users[id].DeletedAt != nil {
+		return nil, ErrUserNotFound
+	}
+	found := *r.users[id]
+	return &found, nil
+}
+
+func (r *userRepository) List(ctx context.Context, offset, limit int) ([]*model.User, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	defer r.rlock(ctx)()
+	ids := make([]string, 0, len(r.users))
+	for id, user := range r.users {
+		if user.DeletedAt == nil {
+			ids = append(ids, id)
 		}
 	}
-	return nil, fmt.Errorf("user not found")
+	sort.Strings(ids)
+	total := len(ids)
+	if offset > total {
+		offset = total
+	}
+	ids = ids[offset:]
+	if limit < len(ids) {
+		ids = ids[:limit]
+	}
+	users := make([]*model.User, len(ids))
+	for i, id := range ids {
+		found := *r.users[id]
+		users[i] = &found
+	}
+	return users, total, nil
 }
 
 func (r *userRepository) Update(ctx context.Context, user *model.User) error {
-	if _, exists := r.users[user.ID]; !exists {
-		return fmt.Errorf("user not found")
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	defer r.lock(ctx)()
+	stored, exists := r.users[user.ID]
+	if !exists || stored.DeletedAt != nil {
+		return ErrUserNotFound
+	}
+	if stored.Version != user.Version {
+		return ErrConflict
 	}
-	r.users[user.ID] = user
+	if id, taken := r.byEmail[emailKey(user.Email)]; taken && id != user.ID {
+		return ErrUserAlreadyExists
+	}
+	delete(r.byEmail, emailKey(stored.Email))
+	user.Version++
+	user.UpdatedAt = time.Now()
+	*stored = *user
+	r.byEmail[emailKey(user.Email)] = user.ID
 	return nil
 }
 
 func (r *userRepository) Delete(ctx context.Context, id string) error {
-	if _, exists := r.users[id]; !exists {
-		return fmt.Errorf("user not found")
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	defer r.lock(ctx)()
+	user, exists := r.users[id]
+	if !exists || user.DeletedAt != nil {
+		return ErrUserNotFound
 	}
-	delete(r.users, id)
+	// The email stays taken, so the user can be restored
+	now := time.Now()
+	user.DeletedAt, user.UpdatedAt = &now, now
+	user.Version++
 	return nil
 }
+
+func (r *userRepository) Restore(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	defer r.lock(ctx)()
+	user, exists := r.users[id]
+	if !exists || user.DeletedAt == nil {
+		return ErrUserNotFound
+	}
+	user.DeletedAt, user.UpdatedAt = nil, time.Now()
+	user.Version++
+	return nil
+}
+
+func (r *userRepository) Ping(ctx context.Context) error {
+	if r.users == nil {
+		return fmt.Errorf("user store not initialized")
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Just-maple/xmux/examples/webapp/internal/user/model"
+)
+
+func TestEmailCaseInsensitive(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository()
+	if err := repo.Create(ctx, &model.User{ID: "u1", Email: "Admin@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Create(ctx, &model.User{ID: "u2", Email: "admin@EXAMPLE.com"}); !errors.Is(err, ErrUserAlreadyExists) {
+		t.Errorf("create with the email in other casing: err = %v, want ErrUserAlreadyExists", err)
+	}
+	user, err := repo.GetByEmail(ctx, "ADMIN@example.COM")
+	if err != nil || user.ID != "u1" {
+		t.Errorf("GetByEmail in other casing = %v, %v; want u1", user, err)
+	}
+}
+
+func TestUpdateEmailTaken(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository()
+	for _, user := range []*model.User{{ID: "a", Email: "a@example.com"}, {ID: "b", Email: "b@example.com"}} {
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, err := repo.GetByID(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Email = "B@example.com"
+	if err := repo.Update(ctx, a); !errors.Is(err, ErrUserAlreadyExists) {
+		t.Fatalf("update to another user's email: err = %v, want ErrUserAlreadyExists", err)
+	}
+	for email, id := range map[string]string{"a@example.com": "a", "b@example.com": "b"} {
+		if user, err := repo.GetByEmail(ctx, email); err != nil || user.ID != id {
+			t.Errorf("GetByEmail(%s) = %v, %v; want %s", email, user, err, id)
+		}
+	}
+
+	// Changing the casing of one's own email is not a conflict
+	a.Email = "A@example.com"
+	if err := repo.Update(ctx, a); err != nil {
+		t.Errorf("update own email casing: %v", err)
+	}
+}
+
+func TestWithinTxRollsBack(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository()
+	if err := repo.Create(ctx, &model.User{ID: "a", Email: "a@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	errStep := errors.New("second step failed")
+	err := repo.WithinTx(ctx, func(ctx context.Context) error {
+		if err := repo.Create(ctx, &model.User{ID: "b", Email: "b@example.com"}); err != nil {
+			return err
+		}
+		if err := repo.Delete(ctx, "a"); err != nil {
+			return err
+		}
+		return errStep
+	})
+	if !errors.Is(err, errStep) {
+		t.Fatalf("err = %v, want the error of the failing step", err)
+	}
+	if _, err := repo.GetByID(ctx, "b"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("user created in the failed transaction: err = %v, want ErrUserNotFound", err)
+	}
+	if _, err := repo.GetByEmail(ctx, "b@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("email index kept the rolled back user: err = %v", err)
+	}
+	if _, err := repo.GetByID(ctx, "a"); err != nil {
+		t.Errorf("user deleted in the failed transaction: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var bcryptCost atomic.Int64
+
+func init() {
+	bcryptCost.Store(int64(bcrypt.DefaultCost))
+}
+
+// SetBcryptCost sets the bcrypt cost of password hashes created from now
+// on, e.g. higher for production or bcrypt.MinCost for fast tests.
+// Existing hashes keep their cost and still verify.
+func SetBcryptCost(cost int) error {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt cost %d out of range [%d, %d]", cost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	bcryptCost.Store(int64(cost))
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), int(bcryptCost.Load()))
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func checkPassword(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// dummyHash is a hash no password matches in practice, compared against
+// when a login names no user.
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := hashPassword("no user has this password")
+	return hash
+})
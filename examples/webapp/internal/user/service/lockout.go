@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Just-maple/xmux"
+)
+
+var ErrAccountLocked = xmux.NewDomainError("account_locked", http.StatusTooManyRequests, "too many failed login attempts, try again later")
+
+// LockoutPolicy locks an account for Cooldown after MaxFailures
+// consecutive failed logins within Window.
+type LockoutPolicy struct {
+	MaxFailures int
+	Window      time.Duration
+	Cooldown    time.Duration
+}
+
+var DefaultLockoutPolicy = LockoutPolicy{
+	MaxFailures: 5,
+	Window:      15 * time.Minute,
+	Cooldown:    15 * time.Minute,
+}
+
+// Attempts is the failed login state of an account.
+type Attempts struct {
+	Failures     int
+	FirstFailure time.Time
+	LockedUntil  time.Time
+}
+
+// AttemptStore persists failed login attempts per account key.
+type AttemptStore interface {
+	Get(ctx context.Context, key string) (Attempts, error)
+	Set(ctx context.Context, key string, attempts Attempts) error
+	Delete(ctx context.Context, key string) error
+}
+
+type memoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]Attempts
+}
+
+func NewMemoryAttemptStore() AttemptStore {
+	return &memoryAttemptStore{attempts: make(map[string]Attempts)}
+}
+
+func (s *memoryAttemptStore) Get(ctx context.Context, key string) (Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts[key], nil
+}
+
+func (s *memoryAttemptStore) Set(ctx context.Context, key string, attempts Attempts) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts[key] = attempts
+	return nil
+}
+
+func (s *memoryAttemptStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
+
+// Lockout tracks failed logins per account. Accounts are keyed by their
+// lowercased email, whether or not a user has it, so locking does not
+// reveal which emails are registered.
+type Lockout struct {
+	policy LockoutPolicy
+	store  AttemptStore
+	now    func() time.Time
+}
+
+func NewLockout(policy LockoutPolicy, store AttemptStore) *Lockout {
+	if store == nil {
+		store = NewMemoryAttemptStore()
+	}
+	return &Lockout{policy: policy, store: store, now: time.Now}
+}
+
+func lockoutKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Check returns ErrAccountLocked while the account is locked.
+func (l *Lockout) Check(ctx context.Context, email string) error {
+	attempts, err := l.store.Get(ctx, lockoutKey(email))
+	if err != nil {
+		return err
+	}
+	if l.now().Before(attempts.LockedUntil) {
+		return ErrAccountLocked
+	}
+	return nil
+}
+
+// Fail records a failed login, locking the account once the policy's
+// maximum is reached within its window.
+func (l *Lockout) Fail(ctx context.Context, email string) error {
+	key := lockoutKey(email)
+	attempts, err := l.store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	now := l.now()
+	if attempts.Failures == 0 || now.Sub(attempts.FirstFailure) > l.policy.Window {
+		attempts = Attempts{FirstFailure: now}
+	}
+	attempts.Failures++
+	if attempts.Failures >= l.policy.MaxFailures {
+		attempts = Attempts{LockedUntil: now.Add(l.policy.Cooldown)}
+	}
+	return l.store.Set(ctx, key, attempts)
+}
+
+// Succeed resets the failed login count of the account.
+func (l *Lockout) Succeed(ctx context.Context, email string) error {
+	return l.store.Delete(ctx, lockoutKey(email))
+}
@@ -2,44 +2,335 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"github.com/Just-maple/xmux"
 	"github.com/Just-maple/xmux/examples/webapp/internal/user/model"
 	"github.com/Just-maple/xmux/examples/webapp/internal/user/repository"
+	"github.com/Just-maple/xmux/examples/webapp/pkg/audit"
+	"github.com/Just-maple/xmux/examples/webapp/pkg/auth"
+	"github.com/Just-maple/xmux/examples/webapp/pkg/logging"
+	"github.com/Just-maple/xmux/examples/webapp/pkg/mail"
+	"net/http"
+	netmail "net/mail"
 	"time"
 )
 
+var (
+	ErrInvalidCredentials = xmux.NewDomainError("credentials_invalid", http.StatusUnauthorized, "invalid email or password")
+	ErrNameRequired       = xmux.NewDomainError("name_required", http.StatusBadRequest, "name is required")
+	ErrEmailRequired      = xmux.NewDomainError("email_required", http.StatusBadRequest, "email is required")
+	ErrEmailInvalid       = xmux.NewDomainError("email_invalid", http.StatusBadRequest, "email is invalid")
+)
+
 type UserService struct {
-	repo repository.UserRepository
+	repo      repository.UserRepository
+	tokens    *auth.TokenService
+	audit     audit.Logger
+	password  PasswordPolicy
+	lockout   *Lockout
+	refresh   *auth.RefreshService
+	blocklist *auth.Blocklist
+	verify    *Verification
+	mailer    mail.Mailer
 }
 
-func NewUserService(repo repository.UserRepository) *UserService {
-	return &UserService{repo: repo}
+// NewUserService creates the user service. A nil audit logger discards
+// the audit trail, a nil lockout disables account lockout, a nil
+// verification disables sending verification tokens and a nil mailer
+// discards emails.
+func NewUserService(repo repository.UserRepository, tokens *auth.TokenService, refresh *auth.RefreshService, blocklist *auth.Blocklist, auditLogger audit.Logger, policy PasswordPolicy, lockout *Lockout, verification *Verification, mailer mail.Mailer) *UserService {
+	if auditLogger == nil {
+		auditLogger = audit.Nop{}
+	}
+	if mailer == nil {
+		mailer = mail.Nop{}
+	}
+	return &UserService{repo: repo, tokens: tokens, refresh: refresh, blocklist: blocklist, audit: auditLogger, password: policy, lockout: lockout, verify: verification, mailer: mailer}
 }
 
 func (s *UserService) CreateUser(ctx context.Context, req *model.CreateUserRequest) (*model.UserResponse, error) {
-	if req.Name == "" {
-		return nil, fmt.Errorf("name is required")
+	user, err := s.newUser(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The user is only kept if its verification token could be issued
+	var token string
+	err = s.repo.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, user); err != nil {
+			return err
+		}
+		var err error
+		token, err = s.issueVerificationToken(ctx, user)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.created(ctx, user, token)
+
+	return toUserResponse(user), nil
+}
+
+// CreateUsers validates and creates every user of reqs, reporting the
+// outcome of each one, so a bad record does not abort the batch unless
+// mode is repository.AllOrNothing.
+func (s *UserService) CreateUsers(ctx context.Context, reqs []*model.CreateUserRequest, mode repository.BatchMode) (*model.BatchResult, error) {
+	result := &model.BatchResult{Items: make([]model.BatchItem, len(reqs))}
+	users := make([]*model.User, 0, len(reqs))
+	indexes := make([]int, 0, len(reqs))
+	for i, req := range reqs {
+		result.Items[i].Index = i
+		user, err := s.newUser(req)
+		if err != nil {
+			result.Items[i].Error, result.Items[i].Code = err.Error(), xmux.ErrorCode(err)
+			result.Failed++
+			continue
+		}
+		users = append(users, user)
+		indexes = append(indexes, i)
+	}
+
+	var errs []error
+	if result.Failed > 0 && mode == repository.AllOrNothing {
+		errs = make([]error, len(users))
+		for j := range errs {
+			errs[j] = repository.ErrBatchAborted
+		}
+	} else {
+		var err error
+		if errs, err = s.repo.CreateBatch(ctx, users, mode); err != nil {
+			return nil, err
+		}
+	}
+
+	for j, user := range users {
+		item := &result.Items[indexes[j]]
+		if errs[j] != nil {
+			item.Error, item.Code = errs[j].Error(), xmux.ErrorCode(errs[j])
+			result.Failed++
+			continue
+		}
+		token, err := s.issueVerificationToken(ctx, user)
+		if err != nil {
+			logging.LoggerFromContext(ctx).Error("issue verification token", "user_id", user.ID, "error", err)
+		}
+		s.created(ctx, user, token)
+		item.User = toUserResponse(user)
+		result.Created++
+	}
+	return result, nil
+}
+
+// BatchCreateUsers creates the users of the request, answering 207 with
+// the per-user outcomes if any of them failed.
+func (s *UserService) BatchCreateUsers(ctx context.Context, req *model.BatchCreateUsersRequest) (*model.BatchResult, error) {
+	mode := repository.BestEffort
+	if req.AllOrNothing {
+		mode = repository.AllOrNothing
+	}
+	result, err := s.CreateUsers(ctx, req.Users, mode)
+	if err != nil {
+		return nil, err
+	}
+	if result.Failed > 0 {
+		return nil, &xmux.PartialError{
+			Status: http.StatusMultiStatus,
+			Body:   result,
+			Err:    fmt.Errorf("%d of %d users not created", result.Failed, len(req.Users)),
+		}
+	}
+	return result, nil
+}
+
+// newUser validates req and builds the user to store.
+func (s *UserService) newUser(req *model.CreateUserRequest) (*model.User, error) {
+	if req == nil || req.Name == "" {
+		return nil, ErrNameRequired
 	}
 	if req.Email == "" {
-		return nil, fmt.Errorf("email is required")
+		return nil, ErrEmailRequired
+	}
+	if _, err := netmail.ParseAddress(req.Email); err != nil {
+		return nil, ErrEmailInvalid
+	}
+	if err := s.password.Validate(req.Password); err != nil {
+		return nil, err
+	}
+
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		return nil, err
 	}
 
-	user := &model.User{
-		ID:       fmt.Sprintf("user-%d", time.Now().UnixNano()),
+	id, err := newUserID()
+	if err != nil {
+		return nil, err
+	}
+	return &model.User{
+		ID:       id,
 		Name:     req.Name,
 		Email:    req.Email,
-		Password: req.Password,
+		Password: hash,
+		Role:     model.RoleUser,
+	}, nil
+}
+
+// created audits and announces a committed user, and mails token if a
+// verification token was issued.
+func (s *UserService) created(ctx context.Context, user *model.User, token string) {
+	logging.LoggerFromContext(ctx).Info("user created", "user_id", user.ID)
+	s.audit.Record(ctx, audit.ActionCreate, user.ID, map[string]string{"email": user.Email})
+	s.send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Welcome",
+		Body:    fmt.Sprintf("Hi %s, your account has been created.", user.Name),
+	})
+	if token != "" {
+		if err := s.verify.mailer.SendVerification(ctx, user.Email, token); err != nil {
+			logging.LoggerFromContext(ctx).Error("send verification", "user_id", user.ID, "error", err)
+		}
+	}
+}
+
+// newUserID returns a random user id, unique even for users created in
+// the same batch.
+func newUserID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return "user-" + hex.EncodeToString(id), nil
+}
+
+// GenerateVerificationToken issues a verification token for the user
+// and mails it to their email address.
+func (s *UserService) GenerateVerificationToken(ctx context.Context, userID string) (string, error) {
+	if s.verify == nil {
+		return "", ErrInvalidVerificationToken
+	}
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	token, err := s.issueVerificationToken(ctx, user)
+	if err != nil {
+		return "", err
+	}
+	if err := s.verify.mailer.SendVerification(ctx, user.Email, token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// issueVerificationToken stores a new verification token for user. It
+// returns an empty token if verification is disabled.
+func (s *UserService) issueVerificationToken(ctx context.Context, user *model.User) (string, error) {
+	if s.verify == nil {
+		return "", nil
+	}
+	token, err := newVerificationToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.verify.store.Save(ctx, token, user.ID, time.Now().Add(s.verify.ttl)); err != nil {
+		return "", err
 	}
+	return token, nil
+}
 
-	if err := s.repo.Create(ctx, user); err != nil {
+// VerifyEmail marks the email of the user the token was issued to as
+// verified. Each token can be used once.
+func (s *UserService) VerifyEmail(ctx context.Context, req *model.VerifyEmailRequest) error {
+	if s.verify == nil {
+		return ErrInvalidVerificationToken
+	}
+	userID, err := s.verify.store.Take(ctx, req.Token)
+	if err != nil {
+		return err
+	}
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	user.Verified = true
+	return s.repo.Update(ctx, user)
+}
+
+func (s *UserService) Login(ctx context.Context, req *model.LoginRequest) (*model.LoginResponse, error) {
+	if s.lockout != nil {
+		if err := s.lockout.Check(ctx, req.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	// Unknown emails are checked against a dummy hash, so the error and
+	// the time taken are the same as for a wrong password
+	user, err := s.repo.GetByEmail(ctx, req.Email)
+	hash := dummyHash()
+	if err == nil {
+		hash = user.Password
+	}
+	if !checkPassword(hash, req.Password) || err != nil {
+		if s.lockout != nil {
+			if err := s.lockout.Fail(ctx, req.Email); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrInvalidCredentials
+	}
+	if s.lockout != nil {
+		if err := s.lockout.Succeed(ctx, req.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := s.tokens.Generate(user.ID, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := s.tokens.GenerateRefresh(user.ID, user.Role)
+	if err != nil {
 		return nil, err
 	}
+	return &model.LoginResponse{Token: token, RefreshToken: refresh}, nil
+}
 
-	return &model.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
-	}, nil
+// Refresh exchanges a refresh token for a new token pair. The presented
+// refresh token cannot be used again.
+func (s *UserService) Refresh(ctx context.Context, req *model.RefreshRequest) (*model.LoginResponse, error) {
+	token, refresh, err := s.refresh.Exchange(ctx, req.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	return &model.LoginResponse{Token: token, RefreshToken: refresh}, nil
+}
+
+// Logout revokes the access token of the request until it expires.
+func (s *UserService) Logout(ctx context.Context, req *model.LogoutRequest) error {
+	claims, ok := auth.ClaimsFromContext(ctx)
+	if !ok {
+		return auth.ErrUnauthorized
+	}
+	return s.blocklist.Revoke(ctx, claims)
+}
+
+// GetProfile returns the authenticated user. The user id comes from the
+// auth context, never from request params.
+func (s *UserService) GetProfile(ctx context.Context) (*model.UserResponse, error) {
+	userID, _, err := auth.MustCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toUserResponse(user), nil
 }
 
 func (s *UserService) GetUser(ctx context.Context, req *model.GetUserRequest) (*model.UserResponse, error) {
@@ -48,11 +339,26 @@ func (s *UserService) GetUser(ctx context.Context, req *model.GetUserRequest) (*
 		return nil, err
 	}
 
-	return &model.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
-	}, nil
+	return toUserResponse(user), nil
+}
+
+// ListUsers returns a page of users. The limit is bounded by the
+// xmux.Paginate middleware.
+func (s *UserService) ListUsers(ctx context.Context, req *model.ListUsersRequest) (*model.ListUsersResponse, error) {
+	users, total, err := s.repo.List(ctx, req.Offset, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &model.ListUsersResponse{
+		Users:  make([]*model.UserResponse, len(users)),
+		Total:  total,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}
+	for i, user := range users {
+		resp.Users[i] = toUserResponse(user)
+	}
+	return resp, nil
 }
 
 func (s *UserService) UpdateUser(ctx context.Context, req *model.UpdateUserRequest) (*model.UserResponse, error) {
@@ -61,21 +367,79 @@ func (s *UserService) UpdateUser(ctx context.Context, req *model.UpdateUserReque
 		return nil, err
 	}
 
-	if req.Name != "" {
-		user.Name = req.Name
+	if name, ok := req.Name.Get(); ok {
+		if name == "" {
+			return nil, ErrNameRequired
+		}
+		user.Name = name
+	}
+	if email, ok := req.Email.Get(); ok {
+		if email == "" {
+			return nil, ErrEmailRequired
+		}
+		user.Email = email
 	}
 
 	if err := s.repo.Update(ctx, user); err != nil {
 		return nil, err
 	}
+	s.audit.Record(ctx, audit.ActionUpdate, user.ID, map[string]string{"name": user.Name, "email": user.Email})
 
-	return &model.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
-	}, nil
+	return toUserResponse(user), nil
+}
+
+func (s *UserService) ChangePassword(ctx context.Context, req *model.ChangePasswordRequest) error {
+	user, err := s.repo.GetByID(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	if !checkPassword(user.Password, req.OldPassword) {
+		return ErrInvalidCredentials
+	}
+	if err := s.password.Validate(req.NewPassword); err != nil {
+		return err
+	}
+
+	hash, err := hashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	user.Password = hash
+	if err := s.repo.Update(ctx, user); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, audit.ActionPasswordChange, user.ID, nil)
+	s.send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Your password was changed",
+		Body:    fmt.Sprintf("Hi %s, the password of your account has been changed.", user.Name),
+	})
+	return nil
 }
 
 func (s *UserService) DeleteUser(ctx context.Context, req *model.DeleteUserRequest) error {
-	return s.repo.Delete(ctx, req.ID)
+	if err := s.repo.Delete(ctx, req.ID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, audit.ActionDelete, req.ID, nil)
+	return nil
+}
+
+// send delivers msg once the change it reports has been stored. A
+// failed email does not undo the change, so the error is only logged.
+func (s *UserService) send(ctx context.Context, msg mail.Message) {
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		logging.LoggerFromContext(ctx).Error("send email", "subject", msg.Subject, "error", err)
+	}
+}
+
+func toUserResponse(user *model.User) *model.UserResponse {
+	return &model.UserResponse{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		Verified:  user.Verified,
+		UpdatedAt: user.UpdatedAt,
+	}
 }
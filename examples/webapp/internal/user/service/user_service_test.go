@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Just-maple/xmux/examples/webapp/internal/user/model"
+	"github.com/Just-maple/xmux/examples/webapp/internal/user/repository"
+)
+
+var errStore = errors.New("verification store unavailable")
+
+// failingStore fails to save verification tokens.
+type failingStore struct{}
+
+func (failingStore) Save(ctx context.Context, token string, userID string, expiresAt time.Time) error {
+	return errStore
+}
+
+func (failingStore) Take(ctx context.Context, token string) (string, error) {
+	return "", ErrInvalidVerificationToken
+}
+
+func TestCreateUserRollsBackOnFailure(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewUserRepository()
+	svc := NewUserService(repo, nil, nil, nil, nil, DefaultPasswordPolicy, nil,
+		NewVerification(failingStore{}, NopVerificationMailer{}, time.Hour), nil)
+
+	_, err := svc.CreateUser(ctx, &model.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Password: "Secret123!"})
+	if !errors.Is(err, errStore) {
+		t.Fatalf("err = %v, want the verification store error", err)
+	}
+	if _, err := repo.GetByEmail(ctx, "alice@example.com"); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Errorf("user kept after the transaction failed: err = %v", err)
+	}
+}
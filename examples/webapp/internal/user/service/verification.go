@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Just-maple/xmux"
+	"github.com/Just-maple/xmux/examples/webapp/internal/user/repository"
+	"github.com/Just-maple/xmux/examples/webapp/pkg/auth"
+)
+
+var (
+	ErrInvalidVerificationToken = xmux.NewDomainError("verification_token_invalid", http.StatusBadRequest, "invalid or expired verification token")
+	ErrEmailNotVerified         = xmux.NewDomainError("email_not_verified", http.StatusForbidden, "email not verified")
+)
+
+// OptionRequireVerified is the route option key restricting a route to
+// users with a verified email. It is enforced by RequireVerified.
+const OptionRequireVerified = "require_verified"
+
+// Verified returns a route option restricting access to users with a
+// verified email.
+func Verified() map[string]string {
+	return map[string]string{OptionRequireVerified: "true"}
+}
+
+// VerificationStore persists email verification tokens.
+type VerificationStore interface {
+	Save(ctx context.Context, token string, userID string, expiresAt time.Time) error
+	// Take returns the user id of token and removes it, so a token
+	// verifies an email once. Unknown or expired tokens return
+	// ErrInvalidVerificationToken.
+	Take(ctx context.Context, token string) (string, error)
+}
+
+type verificationEntry struct {
+	userID    string
+	expiresAt time.Time
+}
+
+type memoryVerificationStore struct {
+	mu     sync.Mutex
+	tokens map[string]verificationEntry
+}
+
+func NewMemoryVerificationStore() VerificationStore {
+	return &memoryVerificationStore{tokens: make(map[string]verificationEntry)}
+}
+
+func (s *memoryVerificationStore) Save(ctx context.Context, token string, userID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token] = verificationEntry{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *memoryVerificationStore) Take(ctx context.Context, token string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.tokens[token]
+	delete(s.tokens, token)
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return "", ErrInvalidVerificationToken
+	}
+	return entry.userID, nil
+}
+
+// VerificationMailer delivers verification tokens to users.
+type VerificationMailer interface {
+	SendVerification(ctx context.Context, email string, token string) error
+}
+
+// NopVerificationMailer discards verification emails.
+type NopVerificationMailer struct{}
+
+func (NopVerificationMailer) SendVerification(ctx context.Context, email string, token string) error {
+	return nil
+}
+
+// Verification issues email verification tokens valid for TTL.
+type Verification struct {
+	store  VerificationStore
+	mailer VerificationMailer
+	ttl    time.Duration
+}
+
+func NewVerification(store VerificationStore, mailer VerificationMailer, ttl time.Duration) *Verification {
+	if mailer == nil {
+		mailer = NopVerificationMailer{}
+	}
+	return &Verification{store: store, mailer: mailer, ttl: ttl}
+}
+
+// RequireVerified returns a middleware enforcing the Verified route
+// option: the authenticated user must have verified their email,
+// otherwise ErrEmailNotVerified is returned. It must be installed after
+// auth.Authenticate.
+func RequireVerified(repo repository.UserRepository) xmux.Middleware {
+	return func(next xmux.Invoker) xmux.Invoker {
+		return func(ctx context.Context, bind func(params any) error) (any, error) {
+			if xmux.RouteOptionsFromContext(ctx)[OptionRequireVerified] != "true" {
+				return next(ctx, bind)
+			}
+			userID, _, err := auth.MustCurrentUser(ctx)
+			if err != nil {
+				return nil, err
+			}
+			user, err := repo.GetByID(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			if !user.Verified {
+				return nil, ErrEmailNotVerified
+			}
+			return next(ctx, bind)
+		}
+	}
+}
+
+func newVerificationToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
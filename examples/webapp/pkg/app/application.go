@@ -1,18 +1,21 @@
 package app
 
 import (
-	"context"
 	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/Just-maple/godi"
 
 	"github.com/Just-maple/xmux"
 	orderService "github.com/Just-maple/xmux/examples/webapp/internal/order/service"
-	productModel "github.com/Just-maple/xmux/examples/webapp/internal/product/model"
 	productService "github.com/Just-maple/xmux/examples/webapp/internal/product/service"
 	userModel "github.com/Just-maple/xmux/examples/webapp/internal/user/model"
+	userRepository "github.com/Just-maple/xmux/examples/webapp/internal/user/repository"
 	userService "github.com/Just-maple/xmux/examples/webapp/internal/user/service"
+	"github.com/Just-maple/xmux/examples/webapp/pkg/auth"
+	"github.com/Just-maple/xmux/examples/webapp/pkg/logging"
+	"github.com/Just-maple/xmux/health"
 )
 
 type Application struct {
@@ -30,27 +33,70 @@ func (a *Application) RegisterRoutes(ctrl xmux.Controller) {
 		return godi.InjectAs(a.container, ptr)
 	}
 
+	tokens, err := godi.Inject[*auth.TokenService](a.container)
+	if err != nil {
+		log.Printf("Error resolving token service: %v", err)
+		return
+	}
+
+	blocklist, err := godi.Inject[*auth.Blocklist](a.container)
+	if err != nil {
+		log.Printf("Error resolving token blocklist: %v", err)
+		return
+	}
+
+	users, err := godi.Inject[userRepository.UserRepository](a.container)
+	if err != nil {
+		log.Printf("Error resolving user repository: %v", err)
+		return
+	}
+
+	if err := Routes(tokens, blocklist, users).Bind(ctrl, bindService); err != nil {
+		log.Printf("Error binding routes: %v", err)
+	} else {
+		log.Println("All routes registered successfully")
+	}
+}
+
+// Routes returns the route groups of the application. Their services are
+// resolved by the bind function passed to Bind.
+func Routes(tokens *auth.TokenService, blocklist *auth.Blocklist, users userRepository.UserRepository) xmux.Binder {
+	checker := health.New()
+	checker.RegisterCheck("users", users.Ping)
+
+	publicUserGroup := xmux.ServiceGroup(func(r xmux.Router, svc *userService.UserService) {
+		log.Println("Registering public user routes")
+		xmux.RegisterCreated(r, http.MethodPost, "/api/users", svc.CreateUser, func(user *userModel.UserResponse) string {
+			return "/api/users/" + user.ID
+		})
+		xmux.Register(r, http.MethodPost, "/api/users/login", svc.Login)
+		xmux.Register(r, http.MethodPost, "/api/users/refresh", svc.Refresh)
+		xmux.RegisterNoContent(r, http.MethodPost, "/api/users/verify", svc.VerifyEmail)
+	}, xmux.Consumes("application/json"), xmux.RequireBody())
+
 	userGroup := xmux.ServiceGroup(func(r xmux.Router, svc *userService.UserService) {
 		log.Println("Registering user routes")
-		xmux.Register(r, http.MethodPost, "/api/users", svc.CreateUser)
-		xmux.Register(r, http.MethodGet, "/api/users/:id", svc.GetUser)
-		xmux.Register(r, http.MethodPut, "/api/users/:id", svc.UpdateUser)
-		xmux.Register(r, http.MethodDelete, "/api/users/:id", func(ctx context.Context, req *userModel.DeleteUserRequest) (any, error) {
-			return nil, svc.DeleteUser(ctx, req)
-		})
+		xmux.RegisterNoParams(r, http.MethodGet, "/api/users/me", svc.GetProfile)
+		xmux.Register(r, http.MethodGet, "/api/users", svc.ListUsers, xmux.WithRoles(userModel.RoleAdmin), xmux.SparseFields())
+		xmux.Register(r, http.MethodPost, "/api/users/batch", svc.BatchCreateUsers, xmux.WithRoles(userModel.RoleAdmin))
+		xmux.RegisterNoContent(r, http.MethodPost, "/api/users/logout", svc.Logout)
+
+		// Routes acting on an account are limited to its owner and admins
+		owned := xmux.WithMiddleware(r, auth.RequireSelfOrRole(userModel.RoleAdmin))
+		xmux.Register(owned, http.MethodGet, "/api/users/:id", svc.GetUser, xmux.SparseFields())
+		xmux.Register(owned, http.MethodPut, "/api/users/:id", svc.UpdateUser)
+		xmux.Register(owned, http.MethodPatch, "/api/users/:id", svc.UpdateUser)
+		xmux.RegisterNoContent(owned, http.MethodPost, "/api/users/:id/change-password", svc.ChangePassword, userService.Verified())
+		xmux.RegisterNoContent(owned, http.MethodDelete, "/api/users/:id", svc.DeleteUser)
 	})
 
 	productGroup := xmux.ServiceGroup(func(r xmux.Router, svc *productService.ProductService) {
 		log.Println("Registering product routes")
 		xmux.Register(r, http.MethodPost, "/api/products", svc.CreateProduct)
 		xmux.Register(r, http.MethodGet, "/api/products/:id", svc.GetProduct)
-		xmux.Register(r, http.MethodGet, "/api/products", func(ctx context.Context, _ *struct{}) ([]*productModel.ProductResponse, error) {
-			return svc.ListProducts(ctx)
-		})
+		xmux.RegisterNoParams(r, http.MethodGet, "/api/products", svc.ListProducts)
 		xmux.Register(r, http.MethodPut, "/api/products/:id", svc.UpdateProduct)
-		xmux.Register(r, http.MethodDelete, "/api/products/:id", func(ctx context.Context, req *productModel.DeleteProductRequest) (any, error) {
-			return nil, svc.DeleteProduct(ctx, req)
-		})
+		xmux.RegisterNoContent(r, http.MethodDelete, "/api/products/:id", svc.DeleteProduct)
 	})
 
 	orderGroup := xmux.ServiceGroup(func(r xmux.Router, svc *orderService.OrderService) {
@@ -59,11 +105,11 @@ func (a *Application) RegisterRoutes(ctrl xmux.Controller) {
 		xmux.Register(r, http.MethodGet, "/api/orders/:id", svc.GetOrder)
 	})
 
-	groups := xmux.NewGroups(userGroup, productGroup, orderGroup)
-
-	if err := groups.Bind(ctrl, bindService); err != nil {
-		log.Printf("Error binding routes: %v", err)
-	} else {
-		log.Println("All routes registered successfully")
-	}
+	return xmux.Use(xmux.NewGroups(
+		health.Group(checker),
+		xmux.Use(publicUserGroup, xmux.RateLimit(5, 10)),
+		xmux.Use(userGroup, auth.Authenticate(tokens, blocklist), xmux.RequireRoles(), userService.RequireVerified(users)),
+		productGroup,
+		orderGroup,
+	), logging.Middleware(slog.Default()), xmux.Paginate(xmux.PageLimits{Default: 10, Max: 100}))
 }
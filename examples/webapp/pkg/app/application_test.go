@@ -0,0 +1,219 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	userRepository "github.com/Just-maple/xmux/examples/webapp/internal/user/repository"
+	userService "github.com/Just-maple/xmux/examples/webapp/internal/user/service"
+	"github.com/Just-maple/xmux/examples/webapp/pkg/audit"
+	"github.com/Just-maple/xmux/examples/webapp/pkg/auth"
+	"github.com/Just-maple/xmux/examples/webapp/pkg/controller"
+	"github.com/Just-maple/xmux/examples/webapp/pkg/mail"
+	"github.com/Just-maple/xmux/xhttp"
+)
+
+// testApp serves the routes of the application with in-memory
+// dependencies wired as in di.BuildContainer.
+type testApp struct {
+	t       *testing.T
+	handler http.Handler
+	tokens  *auth.TokenService
+}
+
+func newTestApp(t *testing.T) *testApp {
+	t.Helper()
+	users := userRepository.NewUserRepository()
+	tokens := auth.NewTokenService([]byte("test-secret"), time.Hour, 24*time.Hour)
+	blocklist := auth.NewBlocklist(auth.NewMemoryRevocationStore())
+	svc := userService.NewUserService(users, tokens,
+		auth.NewRefreshService(tokens, auth.NewMemoryRevocationStore()), blocklist, audit.Nop{},
+		userService.DefaultPasswordPolicy,
+		userService.NewLockout(userService.DefaultLockoutPolicy, userService.NewMemoryAttemptStore()),
+		userService.NewVerification(userService.NewMemoryVerificationStore(), userService.NopVerificationMailer{}, time.Hour),
+		mail.Nop{})
+
+	ctrl := controller.NewController(xhttp.Config{})
+	err := Routes(tokens, blocklist, users).Bind(ctrl, func(ptr any) error {
+		// Only the user routes are exercised
+		if p, ok := ptr.(**userService.UserService); ok {
+			*p = svc
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testApp{t: t, handler: ctrl, tokens: tokens}
+}
+
+// token returns an access token of userID with role.
+func (a *testApp) token(userID string, role string) string {
+	a.t.Helper()
+	token, err := a.tokens.Generate(userID, role)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return token
+}
+
+// do serves a request with the given bearer token and JSON body, either
+// of which may be empty.
+func (a *testApp) do(method string, target string, token string, body string) *httptest.ResponseRecorder {
+	a.t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	a.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// createUser registers a user and returns its id.
+func (a *testApp) createUser(name string, email string) string {
+	a.t.Helper()
+	rec := a.do(http.MethodPost, "/api/users", "", `{"name":"`+name+`","email":"`+email+`","password":"Secret123!"}`)
+	if rec.Code != http.StatusCreated {
+		a.t.Fatalf("create user: status %d: %s", rec.Code, rec.Body)
+	}
+	var user struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &user); err != nil {
+		a.t.Fatal(err)
+	}
+	return user.ID
+}
+
+func TestOwnedRoutesForbidOtherUsers(t *testing.T) {
+	app := newTestApp(t)
+	alice := app.createUser("Alice", "alice@example.com")
+	bob := app.createUser("Bob", "bob@example.com")
+	bobToken := app.token(bob, "user")
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		if rec := app.do(method, "/api/users/"+alice, bobToken, ""); rec.Code != http.StatusForbidden {
+			t.Errorf("%s another user: status %d, want 403: %s", method, rec.Code, rec.Body)
+		}
+	}
+	if rec := app.do(http.MethodGet, "/api/users/"+bob, bobToken, ""); rec.Code != http.StatusOK {
+		t.Errorf("GET own user: status %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if rec := app.do(http.MethodDelete, "/api/users/"+alice, app.token("root", "admin"), ""); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE as admin: status %d, want 204: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestUserEmailVisibility(t *testing.T) {
+	app := newTestApp(t)
+	alice := app.createUser("Alice", "alice@example.com")
+
+	// The anonymous caller creating a user is not its owner
+	rec := app.do(http.MethodPost, "/api/users", "", `{"name":"Bob","email":"bob@example.com","password":"Secret123!"}`)
+	if strings.Contains(rec.Body.String(), "bob@example.com") {
+		t.Errorf("create response shows the email to an anonymous caller: %s", rec.Body)
+	}
+
+	cases := []struct {
+		name   string
+		target string
+		token  string
+		want   bool
+	}{
+		{"admin", "/api/users/" + alice, app.token("root", "admin"), true},
+		{"self", "/api/users/" + alice, app.token(alice, "user"), true},
+		{"self profile", "/api/users/me", app.token(alice, "user"), true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := app.do(http.MethodGet, tc.target, tc.token, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body)
+			}
+			var user map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &user); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := user["email"]; ok != tc.want {
+				t.Errorf("email shown = %v, want %v: %s", ok, tc.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestListUsersSparseFields(t *testing.T) {
+	app := newTestApp(t)
+	app.createUser("Alice", "alice@example.com")
+
+	rec := app.do(http.MethodGet, "/api/users?fields=id", app.token("root", "admin"), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var page struct {
+		Users []map[string]any `json:"users"`
+		Total int              `json:"total"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || len(page.Users) != 1 || len(page.Users[0]) != 1 || page.Users[0]["id"] == nil {
+		t.Errorf("body = %s, want the total and the ids of the users", rec.Body)
+	}
+}
+
+func TestGetProfile(t *testing.T) {
+	app := newTestApp(t)
+	app.createUser("Alice", "alice@example.com")
+	bob := app.createUser("Bob", "bob@example.com")
+
+	rec := app.do(http.MethodGet, "/api/users/me", app.token(bob, "user"), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var user struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &user); err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != bob || user.Name != "Bob" {
+		t.Errorf("profile = %+v, want the user of the token %s", user, bob)
+	}
+
+	if rec := app.do(http.MethodGet, "/api/users/me", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without a token: status %d, want 401", rec.Code)
+	}
+}
+
+func TestLoginFailuresAreIdentical(t *testing.T) {
+	app := newTestApp(t)
+	app.createUser("Alice", "alice@example.com")
+
+	login := func(email string, password string) (int, map[string]any) {
+		rec := app.do(http.MethodPost, "/api/users/login", "", `{"email":"`+email+`","password":"`+password+`"}`)
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		delete(body, "request_id")
+		return rec.Code, body
+	}
+	unknownStatus, unknown := login("nobody@example.com", "Secret123!")
+	wrongStatus, wrong := login("alice@example.com", "Wrong123!")
+	if unknownStatus != http.StatusUnauthorized || wrongStatus != unknownStatus {
+		t.Errorf("status unknown user %d, wrong password %d; want both 401", unknownStatus, wrongStatus)
+	}
+	if !reflect.DeepEqual(unknown, wrong) {
+		t.Errorf("unknown user %v, wrong password %v; want identical errors", unknown, wrong)
+	}
+}
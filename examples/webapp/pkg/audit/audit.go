@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Just-maple/xmux/examples/webapp/pkg/auth"
+)
+
+const (
+	ActionCreate         = "create"
+	ActionUpdate         = "update"
+	ActionDelete         = "delete"
+	ActionPasswordChange = "password_change"
+)
+
+// Logger records mutations of users. The acting user is taken from the
+// auth context of ctx.
+type Logger interface {
+	Record(ctx context.Context, action string, userID string, details any)
+}
+
+// Nop discards every record.
+type Nop struct{}
+
+func (Nop) Record(ctx context.Context, action string, userID string, details any) {}
+
+type Entry struct {
+	Action  string
+	UserID  string
+	ActorID string
+	Details any
+	Time    time.Time
+}
+
+// Memory keeps every record in memory, e.g. for tests.
+type Memory struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Record(ctx context.Context, action string, userID string, details any) {
+	actorID, _, _ := auth.CurrentUser(ctx)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, Entry{
+		Action:  action,
+		UserID:  userID,
+		ActorID: actorID,
+		Details: details,
+		Time:    time.Now(),
+	})
+}
+
+func (m *Memory) Entries() []Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Entry(nil), m.entries...)
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/Just-maple/xmux"
+)
+
+// Owned is implemented by request params acting on a single user's
+// account, such as a password change.
+type Owned interface {
+	OwnerID() string
+}
+
+// RequireSelfOrRole returns a middleware allowing a request only when
+// its params implement Owned and the authenticated user owns the
+// account, or when the user has one of the given roles. Other requests,
+// including those whose params do not implement Owned, are rejected
+// with 403, so the guard fails closed.
+//
+// Install it on the routes acting on an account, inside Authenticate:
+//
+//	owned := xmux.WithMiddleware(r, auth.RequireSelfOrRole("admin"))
+//	xmux.RegisterNoContent(owned, http.MethodDelete, "/api/users/:id", svc.DeleteUser)
+func RequireSelfOrRole(roles ...string) xmux.Middleware {
+	return func(next xmux.Invoker) xmux.Invoker {
+		return func(ctx context.Context, bind func(params any) error) (any, error) {
+			return next(ctx, func(params any) error {
+				if err := bind(params); err != nil {
+					return err
+				}
+				userID, role, err := MustCurrentUser(ctx)
+				if err != nil {
+					return err
+				}
+				if owned, ok := params.(Owned); ok && userID == owned.OwnerID() {
+					return nil
+				}
+				for _, r := range roles {
+					if r == role {
+						return nil
+					}
+				}
+				return xmux.ErrForbidden
+			})
+		}
+	}
+}
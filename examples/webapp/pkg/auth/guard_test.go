@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Just-maple/xmux"
+)
+
+type ownedParams struct{ ID string }
+
+func (p *ownedParams) OwnerID() string { return p.ID }
+
+type unownedParams struct{ ID string }
+
+func TestRequireSelfOrRole(t *testing.T) {
+	guard := RequireSelfOrRole("admin")
+
+	cases := []struct {
+		name    string
+		ctx     context.Context
+		params  any
+		wantErr error
+	}{
+		{"owner", WithCurrentUser(context.Background(), "u1", "user"), &ownedParams{ID: "u1"}, nil},
+		{"other user", WithCurrentUser(context.Background(), "u2", "user"), &ownedParams{ID: "u1"}, xmux.ErrForbidden},
+		{"admin", WithCurrentUser(context.Background(), "a1", "admin"), &ownedParams{ID: "u1"}, nil},
+		{"not owned", WithCurrentUser(context.Background(), "u1", "user"), &unownedParams{ID: "u1"}, xmux.ErrForbidden},
+		{"not owned admin", WithCurrentUser(context.Background(), "a1", "admin"), &unownedParams{ID: "u1"}, nil},
+		{"anonymous", context.Background(), &ownedParams{ID: "u1"}, ErrUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := guard(func(ctx context.Context, bind func(params any) error) (any, error) {
+				return nil, bind(tc.params)
+			})(tc.ctx, func(params any) error { return nil })
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
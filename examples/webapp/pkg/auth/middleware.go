@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Just-maple/xmux"
+)
+
+type contextKey struct{}
+
+type currentUser struct {
+	id   string
+	role string
+}
+
+var ErrUnauthorized = xmux.NewError(http.StatusUnauthorized, "")
+
+// WithCurrentUser stores the authenticated user in the context. The role
+// and id are also stored as the xmux role and subject, so role guards and
+// visible tags see them.
+func WithCurrentUser(ctx context.Context, userID string, role string) context.Context {
+	ctx = context.WithValue(ctx, contextKey{}, currentUser{id: userID, role: role})
+	return xmux.WithSubject(xmux.WithRole(ctx, role), userID)
+}
+
+// CurrentUser returns the id and role of the authenticated user.
+func CurrentUser(ctx context.Context) (userID string, role string, ok bool) {
+	user, ok := ctx.Value(contextKey{}).(currentUser)
+	return user.id, user.role, ok
+}
+
+// MustCurrentUser is like CurrentUser but returns ErrUnauthorized when
+// the request is not authenticated.
+func MustCurrentUser(ctx context.Context) (userID string, role string, err error) {
+	userID, role, ok := CurrentUser(ctx)
+	if !ok {
+		return "", "", ErrUnauthorized
+	}
+	return userID, role, nil
+}
+
+// ClaimsFromContext returns the token claims stored by Authenticate.
+// It fixes the claim type, so handlers and the middleware always agree.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	return xmux.ClaimsFromContext[*Claims](ctx)
+}
+
+// Authenticate verifies the bearer token of the request and stores the
+// user id, role and claims in the context. Requests without a valid
+// token, or with a token in blocklist, are rejected with 401. A nil
+// blocklist accepts every valid token.
+func Authenticate(tokens *TokenService, blocklist *Blocklist) xmux.Middleware {
+	return func(next xmux.Invoker) xmux.Invoker {
+		return func(ctx context.Context, bind func(params any) error) (any, error) {
+			info, ok := xmux.RequestInfoFromContext(ctx)
+			if !ok {
+				return nil, ErrUnauthorized
+			}
+
+			token, ok := strings.CutPrefix(info.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				return nil, ErrUnauthorized
+			}
+
+			claims, err := tokens.Parse(token)
+			if err != nil {
+				return nil, ErrUnauthorized
+			}
+			if blocklist != nil {
+				revoked, err := blocklist.Revoked(ctx, claims)
+				if err != nil {
+					return nil, err
+				}
+				if revoked {
+					return nil, ErrUnauthorized
+				}
+			}
+
+			ctx = WithCurrentUser(ctx, claims.UserID, claims.Role)
+			ctx = xmux.WithClaims(ctx, claims)
+			return next(ctx, bind)
+		}
+	}
+}
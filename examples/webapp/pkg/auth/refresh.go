@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RevocationStore records revoked token ids until the tokens expire.
+type RevocationStore interface {
+	Revoke(ctx context.Context, id string, expiresAt time.Time) error
+	Revoked(ctx context.Context, id string) (bool, error)
+}
+
+// MemoryRevocationStore is an in-memory RevocationStore. Entries are
+// dropped once the token they revoke has expired.
+type MemoryRevocationStore struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{revoked: make(map[string]time.Time)}
+}
+
+func (s *MemoryRevocationStore) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for revokedID, expiry := range s.revoked {
+		if !now.Before(expiry) {
+			delete(s.revoked, revokedID)
+		}
+	}
+	s.revoked[id] = expiresAt
+	return nil
+}
+
+func (s *MemoryRevocationStore) Revoked(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiry, ok := s.revoked[id]
+	return ok && time.Now().Before(expiry), nil
+}
+
+// RefreshService exchanges refresh tokens for new token pairs. Refresh
+// tokens are single use: each exchange revokes the presented token.
+type RefreshService struct {
+	tokens *TokenService
+	store  RevocationStore
+}
+
+func NewRefreshService(tokens *TokenService, store RevocationStore) *RefreshService {
+	return &RefreshService{tokens: tokens, store: store}
+}
+
+// Exchange verifies refresh, revokes it and issues a new access token
+// and refresh token for the same user. Invalid, expired or revoked
+// refresh tokens return ErrUnauthorized.
+func (s *RefreshService) Exchange(ctx context.Context, refresh string) (access string, newRefresh string, err error) {
+	claims, err := s.tokens.ParseRefresh(refresh)
+	if err != nil {
+		return "", "", ErrUnauthorized
+	}
+	revoked, err := s.store.Revoked(ctx, claims.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if revoked {
+		return "", "", ErrUnauthorized
+	}
+	if err := s.store.Revoke(ctx, claims.ID, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		return "", "", err
+	}
+
+	access, err = s.tokens.Generate(claims.UserID, claims.Role)
+	if err != nil {
+		return "", "", err
+	}
+	newRefresh, err = s.tokens.GenerateRefresh(claims.UserID, claims.Role)
+	if err != nil {
+		return "", "", err
+	}
+	return access, newRefresh, nil
+}
+
+// Blocklist rejects access tokens revoked before they expire, e.g. on
+// logout. Authenticate consults it for every request.
+type Blocklist struct {
+	store RevocationStore
+}
+
+func NewBlocklist(store RevocationStore) *Blocklist {
+	return &Blocklist{store: store}
+}
+
+// Revoke blocks the token with the given claims until it expires.
+func (b *Blocklist) Revoke(ctx context.Context, claims *Claims) error {
+	return b.store.Revoke(ctx, claims.ID, time.Unix(claims.ExpiresAt, 0))
+}
+
+// Revoked reports whether the token with the given claims was revoked.
+func (b *Blocklist) Revoked(ctx context.Context, claims *Claims) (bool, error) {
+	return b.store.Revoked(ctx, claims.ID)
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+const (
+	TypeAccess  = ""
+	TypeRefresh = "refresh"
+)
+
+type Claims struct {
+	ID        string `json:"jti"`
+	Type      string `json:"typ,omitempty"`
+	UserID    string `json:"sub"`
+	Role      string `json:"role"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// TokenService issues and verifies HMAC-SHA256 signed access and refresh
+// tokens of the form base64url(claims).base64url(signature).
+type TokenService struct {
+	secret     []byte
+	ttl        time.Duration
+	refreshTTL time.Duration
+}
+
+func NewTokenService(secret []byte, ttl time.Duration, refreshTTL time.Duration) *TokenService {
+	return &TokenService{secret: secret, ttl: ttl, refreshTTL: refreshTTL}
+}
+
+func (s *TokenService) Generate(userID string, role string) (string, error) {
+	return s.generate(TypeAccess, userID, role, s.ttl)
+}
+
+// GenerateRefresh issues a long lived refresh token, accepted only by
+// ParseRefresh.
+func (s *TokenService) GenerateRefresh(userID string, role string) (string, error) {
+	return s.generate(TypeRefresh, userID, role, s.refreshTTL)
+}
+
+func (s *TokenService) generate(typ string, userID string, role string, ttl time.Duration) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(Claims{
+		ID:        hex.EncodeToString(id),
+		Type:      typ,
+		UserID:    userID,
+		Role:      role,
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + s.sign(encoded), nil
+}
+
+// Parse verifies an access token. Refresh tokens are rejected.
+func (s *TokenService) Parse(token string) (*Claims, error) {
+	return s.parse(TypeAccess, token)
+}
+
+// ParseRefresh verifies a refresh token. Access tokens are rejected.
+func (s *TokenService) ParseRefresh(token string) (*Claims, error) {
+	return s.parse(TypeRefresh, token)
+}
+
+func (s *TokenService) parse(typ string, token string) (*Claims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(encoded))) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Type != typ {
+		return nil, ErrInvalidToken
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+	return &claims, nil
+}
+
+func (s *TokenService) sign(encoded string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -3,44 +3,48 @@ package controller
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/Just-maple/xmux"
+	"github.com/Just-maple/xmux/xhttp"
 )
 
 type Controller struct {
 	engine *gin.Engine
+	config xhttp.Config
 }
 
-func NewController() *Controller {
+func NewController(config xhttp.Config) *Controller {
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
 	engine.Use(gin.Recovery())
+	engine.HandleMethodNotAllowed = true
+	engine.NoRoute(gin.WrapH(config.NotFound()))
+	engine.NoMethod(gin.WrapH(config.MethodNotAllowed()))
 	return &Controller{
 		engine: engine,
+		config: config,
 	}
 }
 
 func (c *Controller) Handle(method, path string, api xmux.Api, options ...map[string]string) {
+	handler := c.config.NewHandler(method, path, api, options...)
+	// gin captures the catch-all segment with a leading slash
+	wildcard := xhttp.WildcardName(path)
 	c.engine.Handle(method, path, func(ctx *gin.Context) {
-		bind := func(ptr any) error {
-			if ctx.Request.Body == nil {
-				return nil
+		// Collect path parameters matched by gin
+		params := make(map[string]string, len(ctx.Params))
+		for _, param := range ctx.Params {
+			if param.Key == wildcard {
+				param.Value = strings.TrimPrefix(param.Value, "/")
 			}
-			if err := ctx.ShouldBindJSON(ptr); err != nil {
-				return ctx.ShouldBindQuery(ptr)
-			}
-			return nil
-		}
-
-		result, err := api.Invoke(ctx.Request.Context(), bind)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
+			params[param.Key] = param.Value
 		}
 
-		ctx.JSON(http.StatusOK, result)
+		// Bind, execute business logic and send response
+		handler.ServeHTTP(ctx.Writer, xhttp.WithPathParams(ctx.Request, params))
 	})
 }
 
@@ -0,0 +1,14 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/Just-maple/xmux/xhttp"
+	"github.com/Just-maple/xmux/xmuxtest"
+)
+
+func TestConformance(t *testing.T) {
+	xmuxtest.RunConformance(t, func() xmuxtest.Adapter {
+		return NewController(xhttp.Config{})
+	})
+}
@@ -9,6 +9,11 @@ import (
 	productService "github.com/Just-maple/xmux/examples/webapp/internal/product/service"
 	userRepo "github.com/Just-maple/xmux/examples/webapp/internal/user/repository"
 	userService "github.com/Just-maple/xmux/examples/webapp/internal/user/service"
+	"github.com/Just-maple/xmux/examples/webapp/pkg/audit"
+	"github.com/Just-maple/xmux/examples/webapp/pkg/auth"
+	"github.com/Just-maple/xmux/examples/webapp/pkg/mail"
+	"os"
+	"time"
 )
 
 func BuildContainer() (*godi.Container, func(context.Context, bool), error) {
@@ -24,11 +29,44 @@ func BuildContainer() (*godi.Container, func(context.Context, bool), error) {
 
 	c.MustAdd(
 		godi.Build(func(c *godi.Container) (userRepo.UserRepository, error) {
-			return userRepo.NewUserRepository(), nil
+			return userRepo.New(os.Getenv("DB_DRIVER"), os.Getenv("DB_DSN"))
 		}),
 
-		godi.Build(func(repo userRepo.UserRepository) (*userService.UserService, error) {
-			return userService.NewUserService(repo), nil
+		godi.Build(func(c *godi.Container) (*auth.TokenService, error) {
+			secret := os.Getenv("AUTH_SECRET")
+			if secret == "" {
+				secret = "webapp-development-secret"
+			}
+			return auth.NewTokenService([]byte(secret), time.Hour, 30*24*time.Hour), nil
+		}),
+
+		godi.Build(func(c *godi.Container) (*auth.RefreshService, error) {
+			tokens, _ := godi.Inject[*auth.TokenService](c)
+			return auth.NewRefreshService(tokens, auth.NewMemoryRevocationStore()), nil
+		}),
+
+		godi.Build(func(c *godi.Container) (*auth.Blocklist, error) {
+			return auth.NewBlocklist(auth.NewMemoryRevocationStore()), nil
+		}),
+
+		godi.Build(func(c *godi.Container) (audit.Logger, error) {
+			return audit.Nop{}, nil
+		}),
+
+		godi.Build(func(c *godi.Container) (mail.Mailer, error) {
+			return mail.Nop{}, nil
+		}),
+
+		godi.Build(func(c *godi.Container) (*userService.UserService, error) {
+			repo, _ := godi.Inject[userRepo.UserRepository](c)
+			tokens, _ := godi.Inject[*auth.TokenService](c)
+			refresh, _ := godi.Inject[*auth.RefreshService](c)
+			blocklist, _ := godi.Inject[*auth.Blocklist](c)
+			auditLogger, _ := godi.Inject[audit.Logger](c)
+			mailer, _ := godi.Inject[mail.Mailer](c)
+			lockout := userService.NewLockout(userService.DefaultLockoutPolicy, userService.NewMemoryAttemptStore())
+			verification := userService.NewVerification(userService.NewMemoryVerificationStore(), userService.NopVerificationMailer{}, 24*time.Hour)
+			return userService.NewUserService(repo, tokens, refresh, blocklist, auditLogger, userService.DefaultPasswordPolicy, lockout, verification, mailer), nil
 		}),
 
 		godi.Build(func(c *godi.Container) (productRepo.ProductRepository, error) {
@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Just-maple/xmux"
+)
+
+type contextKey struct{}
+
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// LoggerFromContext returns the logger stored by WithLogger, or
+// slog.Default() if there is none, so callers can always log.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// Middleware stores base in the context of every request, enriched with
+// the request id and the route, so every line logged through
+// LoggerFromContext can be correlated.
+func Middleware(base *slog.Logger) xmux.Middleware {
+	return func(next xmux.Invoker) xmux.Invoker {
+		return func(ctx context.Context, bind func(params any) error) (any, error) {
+			logger := base
+			if id, ok := xmux.RequestIDFromContext(ctx); ok {
+				logger = logger.With("request_id", id)
+			}
+			if pattern, ok := xmux.RoutePatternFromContext(ctx); ok {
+				logger = logger.With("route", pattern)
+			}
+			return next(WithLogger(ctx, logger), bind)
+		}
+	}
+}
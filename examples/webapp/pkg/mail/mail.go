@@ -0,0 +1,48 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends transactional emails.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Nop discards every message.
+type Nop struct{}
+
+func (Nop) Send(ctx context.Context, msg Message) error {
+	return nil
+}
+
+// Recorder keeps every message in memory instead of sending it, e.g.
+// for tests.
+type Recorder struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Send(ctx context.Context, msg Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+func (r *Recorder) Messages() []Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Message(nil), r.messages...)
+}
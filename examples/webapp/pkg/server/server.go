@@ -13,12 +13,15 @@ import (
 	"github.com/Just-maple/xmux/examples/webapp/pkg/app"
 	"github.com/Just-maple/xmux/examples/webapp/pkg/controller"
 	"github.com/Just-maple/xmux/examples/webapp/pkg/di"
+	"github.com/Just-maple/xmux/xhttp"
 )
 
 type ServerConfig struct {
-	Addr         string
-	ReadTimeout  time.Duration
-	WriteTimeout time.Duration
+	Addr           string
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	RequestTimeout time.Duration
+	CORS           xhttp.CORSOptions
 }
 
 type Server struct {
@@ -44,12 +47,15 @@ func NewServer(config ServerConfig) (*Server, error) {
 func (s *Server) Start() error {
 	app := app.NewApplication(s.container)
 
-	ctrl := controller.NewController()
+	ctrl := controller.NewController(xhttp.Config{
+		RequestTimeout:   s.config.RequestTimeout,
+		ValidationStatus: http.StatusUnprocessableEntity,
+	})
 	app.RegisterRoutes(ctrl)
 
 	s.httpServer = &http.Server{
 		Addr:         s.config.Addr,
-		Handler:      ctrl,
+		Handler:      xhttp.CORS(s.config.CORS)(ctrl),
 		ReadTimeout:  s.config.ReadTimeout,
 		WriteTimeout: s.config.WriteTimeout,
 	}
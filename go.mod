@@ -1,3 +1,5 @@
 module github.com/Just-maple/xmux
 
 go 1.18
+
+require golang.org/x/time v0.5.0
@@ -0,0 +1,167 @@
+// Package health provides liveness and readiness endpoints for xmux
+// applications. Readiness runs the registered checks and reports the
+// aggregated status with per-check results.
+//
+// Example:
+//
+//	checker := health.New()
+//	checker.RegisterCheck("database", db.PingContext)
+//	health.Register(router, checker)
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Just-maple/xmux"
+)
+
+// Status values reported for the service and for each check.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// DefaultTimeout bounds the time a readiness check may take.
+var DefaultTimeout = 5 * time.Second
+
+// Report is the aggregated health status.
+type Report struct {
+	// Status is StatusOK when every check passed, StatusFail otherwise
+	Status string `json:"status"`
+
+	// Checks holds the result of each check by name
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// StatusCode returns 200 when the report is healthy and 503 otherwise.
+// Adapters use it as the response status.
+func (r *Report) StatusCode() int {
+	if r.Status != StatusOK {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// check is a registered health check.
+type check struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Checker runs the registered health checks.
+type Checker struct {
+	mu     sync.RWMutex
+	checks []check
+
+	// Timeout bounds each check, DefaultTimeout if zero
+	Timeout time.Duration
+}
+
+// New creates a Checker without checks.
+func New() *Checker {
+	return &Checker{}
+}
+
+// RegisterCheck adds a readiness check. fn returns an error when the
+// dependency it checks is unavailable.
+func (c *Checker) RegisterCheck(name string, fn func(ctx context.Context) error) {
+	c.mu.Lock()
+	c.checks = append(c.checks, check{name: name, fn: fn})
+	c.mu.Unlock()
+}
+
+// Check runs every registered check concurrently and aggregates the results.
+func (c *Checker) Check(ctx context.Context) *Report {
+	c.mu.RLock()
+	checks := append([]check(nil), c.checks...)
+	c.mu.RUnlock()
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, ch := range checks {
+		wg.Add(1)
+		go func(i int, ch check) {
+			defer wg.Done()
+			start := time.Now()
+			result := Result{Status: StatusOK}
+			if err := ch.fn(ctx); err != nil {
+				result = Result{Status: StatusFail, Error: err.Error()}
+			}
+			result.Duration = time.Since(start).String()
+			results[i] = result
+		}(i, ch)
+	}
+	wg.Wait()
+
+	report := &Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, ch := range checks {
+		report.Checks[ch.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// Live reports that the process is up. It runs no checks.
+func (c *Checker) Live(ctx context.Context, _ *struct{}) (*Report, error) {
+	return &Report{Status: StatusOK}, nil
+}
+
+// Ready runs the registered checks.
+func (c *Checker) Ready(ctx context.Context, _ *struct{}) (*Report, error) {
+	return c.Check(ctx), nil
+}
+
+// Register registers GET /healthz (liveness) and GET /readyz (readiness)
+// as raw routes, so they stay at the root under Prefix or VersionedGroup.
+func Register(router xmux.Router, checker *Checker) {
+	xmux.Register(router, http.MethodGet, "/healthz", checker.Live, xmux.Raw())
+	xmux.Register(router, http.MethodGet, "/readyz", checker.Ready, xmux.Raw())
+}
+
+// Group returns a Binder registering the health routes, for use with
+// xmux.NewGroups. It requires no service injection.
+//
+// Example:
+//
+//	groups := xmux.NewGroups(health.Group(checker), userGroup)
+func Group(checker *Checker) xmux.Binder {
+	return group{checker: checker}
+}
+
+// group is the Binder returned by Group.
+type group struct {
+	checker *Checker
+}
+
+// Bind registers the health routes with controller.
+func (g group) Bind(controller xmux.Controller, _ func(service any) error) error {
+	Register(routerFunc(controller.Handle), g.checker)
+	return nil
+}
+
+// routerFunc adapts a Controller's Handle method to xmux.Router.
+type routerFunc func(method string, path string, api xmux.Api, options ...map[string]string)
+
+// Register implements xmux.Router.
+func (fn routerFunc) Register(method string, path string, api xmux.Api, options ...map[string]string) {
+	fn(method, path, api, options...)
+}
@@ -0,0 +1,45 @@
+package xmux
+
+// OnBind returns a Binder calling fn with the routes of binder once it
+// has bound successfully, e.g. to log the route count or publish the
+// routes to an API gateway. Routes are reported as registered by binder:
+// decorators applied to the result, such as Prefix, are not reflected.
+//
+// Example:
+//
+//	userGroup = xmux.OnBind(userGroup, func(routes []xmux.RouteDef) {
+//	    log.Printf("user group: %d routes", len(routes))
+//	})
+func OnBind(binder Binder, fn func(routes []RouteDef)) Binder {
+	return binderFunc(func(controller Controller, bind func(service any) error) error {
+		var routes []RouteDef
+		err := binder.Bind(controllerFunc(func(method string, path string, api Api, options ...map[string]string) {
+			routes = append(routes, RouteDef{Method: method, Path: path, Api: api, Options: options})
+			controller.Handle(method, path, api, options...)
+		}), bind)
+		if err != nil {
+			return err
+		}
+		fn(routes)
+		return nil
+	})
+}
+
+// OnError returns a Binder calling fn with the error binder fails to bind
+// with, e.g. a dependency that cannot be resolved. The error is still
+// returned.
+//
+// Example:
+//
+//	userGroup = xmux.OnError(userGroup, func(err error) {
+//	    log.Printf("user group: %v", err)
+//	})
+func OnError(binder Binder, fn func(err error)) Binder {
+	return binderFunc(func(controller Controller, bind func(service any) error) error {
+		err := binder.Bind(controller, bind)
+		if err != nil {
+			fn(err)
+		}
+		return err
+	})
+}
@@ -0,0 +1,88 @@
+package xmux
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// FieldDescriptor describes a struct field for diagnostics.
+type FieldDescriptor struct {
+	// Name is the Go name of the field
+	Name string
+
+	// Type is the package qualified type name of the field
+	Type string
+
+	// Tag is the struct tag of the field
+	Tag reflect.StructTag
+
+	// Embedded reports whether the field is an embedded struct
+	Embedded bool
+}
+
+// TypeName returns the package qualified name of the type of v, such as
+// "*github.com/app/model.User" for a *model.User, or an empty string for
+// nil. Use it on Api.Params and Api.Response to see what a handler binds
+// and returns.
+//
+// Example:
+//
+//	log.Printf("%s %s: %s -> %s", method, path, xmux.TypeName(api.Params()), xmux.TypeName(api.Response()))
+func TypeName(v any) string {
+	if v == nil {
+		return ""
+	}
+	return typeName(reflect.TypeOf(v))
+}
+
+// typeName qualifies named types with their package path, including the
+// element types of pointers, slices, arrays and maps.
+func typeName(t reflect.Type) string {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name()
+		}
+		return t.PkgPath() + "." + t.Name()
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "*" + typeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + typeName(t.Elem())
+	case reflect.Array:
+		return "[" + strconv.Itoa(t.Len()) + "]" + typeName(t.Elem())
+	case reflect.Map:
+		return "map[" + typeName(t.Key()) + "]" + typeName(t.Elem())
+	}
+	return t.String()
+}
+
+// FieldDescriptors returns the exported fields of the struct v or v
+// points to, in declaration order, or nil if v is not a struct.
+// Embedded structs are reported as single fields with Embedded set.
+func FieldDescriptors(v any) []FieldDescriptor {
+	if v == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var fields []FieldDescriptor
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fields = append(fields, FieldDescriptor{
+			Name:     field.Name,
+			Type:     typeName(field.Type),
+			Tag:      field.Tag,
+			Embedded: field.Anonymous,
+		})
+	}
+	return fields
+}
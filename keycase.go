@@ -0,0 +1,28 @@
+package xmux
+
+// OptionKeyCase is the route option key selecting the casing of the
+// object keys of JSON responses, overriding the adapter default:
+// KeyCaseCamel, KeyCaseSnake or KeyCaseTags.
+const OptionKeyCase = "key_case"
+
+const (
+	// KeyCaseTags keeps the keys given by the json struct tags
+	KeyCaseTags = "tags"
+
+	// KeyCaseCamel writes keys in camelCase (e.g., "fullName")
+	KeyCaseCamel = "camel"
+
+	// KeyCaseSnake writes keys in snake_case (e.g., "full_name")
+	KeyCaseSnake = "snake"
+)
+
+// WithKeyCase returns a route option selecting the casing of the object
+// keys of JSON responses, so one codebase can serve clients expecting
+// different conventions without editing json tags.
+//
+// Example:
+//
+//	xmux.Register(r, http.MethodGet, "/v2/users/:id", svc.GetUser, xmux.WithKeyCase(xmux.KeyCaseCamel))
+func WithKeyCase(keyCase string) map[string]string {
+	return map[string]string{OptionKeyCase: keyCase}
+}
@@ -0,0 +1,157 @@
+package xmux
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// lazyGroup is a service group whose service is resolved, and whose
+// handlers are built, on the first request to any of its routes.
+type lazyGroup[Service any] struct {
+	// register is the function that defines routes for this service
+	register func(router Router, handler Service)
+
+	// options are route-level options that apply to all routes in this group
+	options []map[string]string
+}
+
+// Bind discovers the routes of the group by calling register with a
+// stand-in Service, see discoverService, and registers a placeholder Api for each of them. The
+// service is resolved through bind on the first request.
+func (g lazyGroup[Service]) Bind(controller Controller, bind func(any) error) error {
+	routes, err := g.discover()
+	if err != nil {
+		return err
+	}
+	state := &lazyState[Service]{register: g.register, bind: bind, routes: len(routes)}
+	for i, route := range routes {
+		controller.Handle(route.Method, route.Path, lazyApi[Service]{
+			Api:   route.Api,
+			state: state,
+			index: i,
+		}, joinOptions(g.options, route.Options)...)
+	}
+	return nil
+}
+
+// discover returns the routes register defines for the stand-in Service.
+// A panic, e.g. from calling the service, is returned as an error.
+func (g lazyGroup[Service]) discover() (routes []RouteDef, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("xmux: lazy group of %s: registering routes without the service: %v",
+				reflect.TypeOf((*Service)(nil)).Elem(), r)
+		}
+	}()
+	g.register(registerFunc(func(method string, path string, api Api, options ...map[string]string) {
+		routes = append(routes, RouteDef{Method: method, Path: path, Api: api, Options: options})
+	}), discoverService[Service]())
+	return routes, nil
+}
+
+// discoverService returns the Service register is called with at Bind:
+// the zero value, a nil pointer for pointer services, or for interface
+// services a stub implementing the interface, so method values of it can
+// be taken. Calling a method of the stub panics. Interfaces with
+// unexported methods cannot be stubbed and get the nil interface.
+func discoverService[Service any]() (s Service) {
+	t := reflect.TypeOf((*Service)(nil)).Elem()
+	if t.Kind() != reflect.Interface || t.NumMethod() == 0 {
+		return s
+	}
+	defer func() {
+		_ = recover()
+	}()
+	stub := reflect.StructOf([]reflect.StructField{{Name: "Service", Type: t, Anonymous: true}})
+	return reflect.New(stub).Elem().Interface().(Service)
+}
+
+// lazyState is shared by the routes of a lazy group and initializes them
+// once.
+type lazyState[Service any] struct {
+	once     sync.Once
+	register func(router Router, handler Service)
+	bind     func(any) error
+
+	// routes is the number of routes found at Bind
+	routes int
+
+	// ready is set to 1 once service and apis are initialized
+	ready   uint32
+	service Service
+	apis    []Api
+	err     error
+}
+
+// init resolves the service and builds the Apis of the group, once.
+// A failure is kept and returned to every request.
+func (s *lazyState[Service]) init() error {
+	s.once.Do(func() {
+		if s.err = s.bind(&s.service); s.err != nil {
+			return
+		}
+		s.register(registerFunc(func(method string, path string, api Api, options ...map[string]string) {
+			s.apis = append(s.apis, api)
+		}), s.service)
+		if len(s.apis) != s.routes {
+			s.err = fmt.Errorf("xmux: lazy group of %s registered %d routes, %d at bind",
+				reflect.TypeOf((*Service)(nil)).Elem(), len(s.apis), s.routes)
+			return
+		}
+		atomic.StoreUint32(&s.ready, 1)
+	})
+	return s.err
+}
+
+// lazyApi stands for the route at index of a lazy group. Introspection is
+// answered by the Api found at Bind; Invoke initializes the group first.
+type lazyApi[Service any] struct {
+	Api
+	state *lazyState[Service]
+	index int
+}
+
+// Invoke initializes the group if needed and invokes the route's Api.
+func (api lazyApi[Service]) Invoke(ctx context.Context, bind func(params any) error) (any, error) {
+	if err := api.state.init(); err != nil {
+		return nil, err
+	}
+	return api.state.apis[api.index].Invoke(ctx, bind)
+}
+
+// Service returns the service once resolved, and nil before.
+func (api lazyApi[Service]) Service() (any, reflect.Type) {
+	t := reflect.TypeOf((*Service)(nil)).Elem()
+	if atomic.LoadUint32(&api.state.ready) == 0 {
+		return nil, t
+	}
+	return api.state.service, t
+}
+
+// ServiceGroupLazy is like ServiceGroup, but defers resolving the service
+// to the first request to any of the group's routes, trading the latency
+// of that request for a faster startup. Resolution happens once, even
+// under concurrent requests; if it fails, every request to the group
+// returns the error.
+//
+// To find the routes at Bind, fn is called with a stand-in for the
+// Service: a nil pointer for pointer services, or a stub for interface
+// services. It must only register routes with method values of the
+// service and not call it; the real service is resolved on the first
+// request. Switching a group between eager and
+// lazy is a matter of choosing ServiceGroup or ServiceGroupLazy.
+//
+// Example:
+//
+//	reports := xmux.ServiceGroupLazy(func(r xmux.Router, svc *ReportService) {
+//	    xmux.Register(r, http.MethodGet, "/reports/:id", svc.GetReport)
+//	})
+func ServiceGroupLazy[Service any](fn func(r Router, s Service), options ...map[string]string) Binder {
+	return lazyGroup[Service]{
+		options:  options,
+		register: fn,
+	}
+}
@@ -0,0 +1,73 @@
+package xmux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type greeter interface {
+	Greet(ctx context.Context, params *struct{}) (string, error)
+	Farewell(ctx context.Context, params *struct{}) (string, error)
+}
+
+type englishGreeter struct{}
+
+func (englishGreeter) Greet(ctx context.Context, params *struct{}) (string, error) {
+	return "hello", nil
+}
+
+func (englishGreeter) Farewell(ctx context.Context, params *struct{}) (string, error) {
+	return "bye", nil
+}
+
+func TestServiceGroupLazyInterface(t *testing.T) {
+	apis := make(map[string]Api)
+	controller := controllerFunc(func(method string, path string, api Api, options ...map[string]string) {
+		apis[path] = api
+	})
+	bound := 0
+	group := ServiceGroupLazy(func(r Router, svc greeter) {
+		Register(r, http.MethodGet, "/greet", svc.Greet)
+		Register(r, http.MethodGet, "/farewell", svc.Farewell)
+	})
+	err := group.Bind(controller, func(ptr any) error {
+		bound++
+		p, ok := ptr.(*greeter)
+		if !ok {
+			return errors.New("unexpected service")
+		}
+		*p = englishGreeter{}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(apis) != 2 || bound != 0 {
+		t.Fatalf("bind: %d routes, service resolved %d times; want 2 routes, unresolved", len(apis), bound)
+	}
+
+	for path, want := range map[string]string{"/greet": "hello", "/farewell": "bye"} {
+		result, err := apis[path].Invoke(context.Background(), func(params any) error { return nil })
+		if err != nil || result != want {
+			t.Errorf("%s = %v, %v; want %q", path, result, err, want)
+		}
+	}
+	if bound != 1 {
+		t.Errorf("service resolved %d times, want 1", bound)
+	}
+	if service, _ := apis["/greet"].Service(); service != (englishGreeter{}) {
+		t.Errorf("Service() = %v, want the resolved service", service)
+	}
+}
+
+func TestServiceGroupLazyCallingService(t *testing.T) {
+	group := ServiceGroupLazy(func(r Router, svc greeter) {
+		_, _ = svc.Greet(context.Background(), nil)
+	})
+	err := group.Bind(controllerFunc(func(string, string, Api, ...map[string]string) {}), func(any) error { return nil })
+	if err == nil {
+		t.Fatal("calling the service at Bind did not fail")
+	}
+}
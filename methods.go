@@ -0,0 +1,44 @@
+package xmux
+
+import (
+	"context"
+	"net/http"
+)
+
+// GET registers fn for GET requests to path.
+// It is shorthand for Register with http.MethodGet.
+//
+// Example:
+//
+//	xmux.GET(router, "/users/:id", svc.GetUser)
+func GET[Params any, Response any](router Router, path string, fn func(ctx context.Context, params *Params) (Response, error), options ...map[string]string) {
+	Register(router, http.MethodGet, path, fn, options...)
+}
+
+// POST registers fn for POST requests to path.
+// It is shorthand for Register with http.MethodPost.
+//
+// Example:
+//
+//	xmux.POST(router, "/users", svc.CreateUser)
+func POST[Params any, Response any](router Router, path string, fn func(ctx context.Context, params *Params) (Response, error), options ...map[string]string) {
+	Register(router, http.MethodPost, path, fn, options...)
+}
+
+// PUT registers fn for PUT requests to path.
+// It is shorthand for Register with http.MethodPut.
+func PUT[Params any, Response any](router Router, path string, fn func(ctx context.Context, params *Params) (Response, error), options ...map[string]string) {
+	Register(router, http.MethodPut, path, fn, options...)
+}
+
+// PATCH registers fn for PATCH requests to path.
+// It is shorthand for Register with http.MethodPatch.
+func PATCH[Params any, Response any](router Router, path string, fn func(ctx context.Context, params *Params) (Response, error), options ...map[string]string) {
+	Register(router, http.MethodPatch, path, fn, options...)
+}
+
+// DELETE registers fn for DELETE requests to path.
+// It is shorthand for Register with http.MethodDelete.
+func DELETE[Params any, Response any](router Router, path string, fn func(ctx context.Context, params *Params) (Response, error), options ...map[string]string) {
+	Register(router, http.MethodDelete, path, fn, options...)
+}
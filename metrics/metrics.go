@@ -0,0 +1,230 @@
+// Package metrics records RED metrics (rate, errors, duration) for xmux
+// routes and exposes them in the Prometheus text format.
+//
+// Metrics are labeled by HTTP method and route pattern (e.g. "/users/:id"),
+// never by the concrete request path, so cardinality stays bounded by the
+// number of routes.
+//
+// Example:
+//
+//	m := metrics.New()
+//	groups := xmux.NewGroups(m.Instrument(userGroup))
+//	mux.Handle("/metrics", m.Handler())
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Just-maple/xmux"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the request
+// duration histogram.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// route identifies the series of a route.
+type route struct {
+	method  string
+	pattern string
+}
+
+// series holds the metrics of a single route.
+type series struct {
+	inFlight int64
+	requests map[int]uint64
+	buckets  []uint64
+	count    uint64
+	sum      float64
+}
+
+// Metrics collects request metrics for instrumented routes.
+type Metrics struct {
+	mu      sync.Mutex
+	buckets []float64
+	routes  map[route]*series
+}
+
+// New creates a Metrics using DefaultBuckets.
+func New() *Metrics {
+	return NewWithBuckets(DefaultBuckets)
+}
+
+// NewWithBuckets creates a Metrics with the given histogram bucket upper
+// bounds in seconds.
+func NewWithBuckets(buckets []float64) *Metrics {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Metrics{
+		buckets: buckets,
+		routes:  make(map[route]*series),
+	}
+}
+
+// Instrument returns a Binder recording metrics for every route of binder.
+// The method and route pattern are captured at registration, so no
+// per-request route lookup is needed.
+func (m *Metrics) Instrument(binder xmux.Binder) xmux.Binder {
+	return binderFunc(func(controller xmux.Controller, bind func(service any) error) error {
+		return binder.Bind(controllerFunc(func(method string, path string, api xmux.Api, options ...map[string]string) {
+			controller.Handle(method, path, xmux.Chain(api, m.Middleware(method, path)), options...)
+		}), bind)
+	})
+}
+
+// Middleware returns the middleware recording metrics for the route
+// identified by method and pattern.
+func (m *Metrics) Middleware(method string, pattern string) xmux.Middleware {
+	s := m.series(route{method: method, pattern: pattern})
+	return func(next xmux.Invoker) xmux.Invoker {
+		return func(ctx context.Context, bind func(params any) error) (any, error) {
+			m.mu.Lock()
+			s.inFlight++
+			m.mu.Unlock()
+
+			start := time.Now()
+			result, err := next(ctx, bind)
+			m.observe(s, status(result, err), time.Since(start).Seconds())
+			return result, err
+		}
+	}
+}
+
+// status returns the response status of a handler outcome, mirroring how
+// adapters derive it.
+func status(result any, err error) int {
+	if err != nil {
+		return xmux.StatusCode(err, http.StatusBadRequest)
+	}
+	if coder, ok := result.(interface{ StatusCode() int }); ok {
+		if v := reflect.ValueOf(coder); v.Kind() != reflect.Pointer || !v.IsNil() {
+			return coder.StatusCode()
+		}
+	}
+	return http.StatusOK
+}
+
+// series returns the series of r, creating it if needed.
+func (m *Metrics) series(r route) *series {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.routes[r]
+	if !ok {
+		s = &series{
+			requests: make(map[int]uint64),
+			buckets:  make([]uint64, len(m.buckets)),
+		}
+		m.routes[r] = s
+	}
+	return s
+}
+
+// observe records a finished request.
+func (m *Metrics) observe(s *series, status int, seconds float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.inFlight--
+	s.requests[status]++
+	s.count++
+	s.sum += seconds
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			s.buckets[i]++
+		}
+	}
+}
+
+// Handler returns the http.Handler exposing the metrics in the Prometheus
+// text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = m.WriteText(w)
+	})
+}
+
+// WriteText writes the metrics to w in the Prometheus text format.
+// Series are sorted by route so the output is stable.
+func (m *Metrics) WriteText(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	routes := make([]route, 0, len(m.routes))
+	for r := range m.routes {
+		routes = append(routes, r)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].pattern != routes[j].pattern {
+			return routes[i].pattern < routes[j].pattern
+		}
+		return routes[i].method < routes[j].method
+	})
+
+	var b strings.Builder
+	b.WriteString("# HELP xmux_requests_total Total number of handled requests.\n")
+	b.WriteString("# TYPE xmux_requests_total counter\n")
+	for _, r := range routes {
+		s := m.routes[r]
+		codes := make([]int, 0, len(s.requests))
+		for code := range s.requests {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Fprintf(&b, "xmux_requests_total{%s,status=\"%d\"} %d\n", labels(r), code, s.requests[code])
+		}
+	}
+
+	b.WriteString("# HELP xmux_request_duration_seconds Request handling duration in seconds.\n")
+	b.WriteString("# TYPE xmux_request_duration_seconds histogram\n")
+	for _, r := range routes {
+		s := m.routes[r]
+		for i, bound := range m.buckets {
+			fmt.Fprintf(&b, "xmux_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels(r), strconv.FormatFloat(bound, 'g', -1, 64), s.buckets[i])
+		}
+		fmt.Fprintf(&b, "xmux_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels(r), s.count)
+		fmt.Fprintf(&b, "xmux_request_duration_seconds_sum{%s} %s\n", labels(r), strconv.FormatFloat(s.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "xmux_request_duration_seconds_count{%s} %d\n", labels(r), s.count)
+	}
+
+	b.WriteString("# HELP xmux_requests_in_flight Number of requests being handled.\n")
+	b.WriteString("# TYPE xmux_requests_in_flight gauge\n")
+	for _, r := range routes {
+		fmt.Fprintf(&b, "xmux_requests_in_flight{%s} %d\n", labels(r), m.routes[r].inFlight)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// labelEscaper escapes label values as required by the text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels formats the method and route labels of r.
+func labels(r route) string {
+	return `method="` + labelEscaper.Replace(r.method) + `",route="` + labelEscaper.Replace(r.pattern) + `"`
+}
+
+// controllerFunc adapts a function to xmux.Controller.
+type controllerFunc func(method string, path string, api xmux.Api, options ...map[string]string)
+
+// Handle implements xmux.Controller.
+func (fn controllerFunc) Handle(method string, path string, api xmux.Api, options ...map[string]string) {
+	fn(method, path, api, options...)
+}
+
+// binderFunc adapts a function to xmux.Binder.
+type binderFunc func(controller xmux.Controller, bind func(service any) error) error
+
+// Bind implements xmux.Binder.
+func (fn binderFunc) Bind(controller xmux.Controller, bind func(service any) error) error {
+	return fn(controller, bind)
+}
@@ -0,0 +1,145 @@
+package xmux
+
+import "context"
+
+// Invoker is the function form of Api.Invoke.
+// Middleware operates on Invokers so it stays independent of any framework.
+type Invoker func(ctx context.Context, bind func(params any) error) (any, error)
+
+// Middleware wraps an Invoker with cross-cutting behavior such as
+// authentication, authorization or rate limiting.
+type Middleware func(next Invoker) Invoker
+
+// middlewareApi overrides Invoke of the embedded Api with a wrapped Invoker.
+// All other methods (Params, Response, Service, ...) are delegated unchanged.
+type middlewareApi struct {
+	Api
+	invoke Invoker
+}
+
+// Invoke executes the wrapped Invoker.
+func (api middlewareApi) Invoke(ctx context.Context, bind func(params any) error) (any, error) {
+	return api.invoke(ctx, bind)
+}
+
+// Chain wraps api with the given middleware.
+// The first middleware is the outermost one: it runs first before the handler
+// and last after it.
+//
+// Example:
+//
+//	api = xmux.Chain(api, authenticate, xmux.RequireRoles())
+func Chain(api Api, middleware ...Middleware) Api {
+	if len(middleware) == 0 {
+		return api
+	}
+	return middlewareApi{
+		Api:    api,
+		invoke: chain(api.Invoke, middleware),
+	}
+}
+
+// chain composes middleware around invoke, first middleware outermost.
+func chain(invoke Invoker, middleware []Middleware) Invoker {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		invoke = middleware[i](invoke)
+	}
+	return invoke
+}
+
+// controllerFunc is a function type that implements the Controller interface.
+// It allows decorating a Controller without defining a new type.
+type controllerFunc func(method string, path string, api Api, options ...map[string]string)
+
+// Handle implements the Controller interface for controllerFunc.
+func (fn controllerFunc) Handle(method string, path string, api Api, options ...map[string]string) {
+	fn(method, path, api, options...)
+}
+
+// binderFunc is a function type that implements the Binder interface.
+type binderFunc func(controller Controller, bind func(service any) error) error
+
+// Bind implements the Binder interface for binderFunc.
+func (fn binderFunc) Bind(controller Controller, bind func(service any) error) error {
+	return fn(controller, bind)
+}
+
+// Use wraps every route of binder with the given middleware.
+// Route information (method, path and merged options) is placed in the
+// context before the middleware runs, so middleware such as RequireRoles
+// can act on per-route options.
+//
+// Middleware composes as an onion, as with Chain: the first middleware
+// is the outermost, running first before the handler and last after it.
+// Use applied to the result of Use wraps the inner middleware, so
+// middleware of an enclosing group, e.g. Use(NewGroups(...), logging),
+// runs before the middleware of the groups it contains:
+//
+//	Use(Use(group, a, b), c)  // c → a → b → handler → b → a → c
+//
+// Example:
+//
+//	protected := xmux.Use(userGroup, authenticate, xmux.RequireRoles())
+func Use(binder Binder, middleware ...Middleware) Binder {
+	return binderFunc(func(controller Controller, bind func(service any) error) error {
+		return binder.Bind(controllerFunc(func(method string, path string, api Api, options ...map[string]string) {
+			route := routeInfo{
+				method:  method,
+				path:    path,
+				options: MergeOptions(options, false),
+			}
+			invoke := chain(api.Invoke, middleware)
+			controller.Handle(method, path, middlewareApi{
+				Api: api,
+				invoke: func(ctx context.Context, bind func(params any) error) (any, error) {
+					return invoke(withRoute(ctx, route), bind)
+				},
+			}, options...)
+		}), bind)
+	})
+}
+
+// WithMiddleware returns a Router registering routes with router wrapped
+// in the given middleware, so middleware can apply to single routes of a
+// group rather than all of them. Route middleware runs inside the group
+// middleware added with Use, and its first middleware is the outermost
+// one, as with Chain. See also RouteBuilder.Use.
+//
+// Example:
+//
+//	xmux.RegisterNoContent(xmux.WithMiddleware(r, requireOwner), http.MethodDelete, "/users/:id", svc.DeleteUser)
+func WithMiddleware(router Router, middleware ...Middleware) Router {
+	return registerFunc(func(method string, path string, api Api, options ...map[string]string) {
+		router.Register(method, path, Chain(api, middleware...), options...)
+	})
+}
+
+// WithContext returns a middleware deriving the context of every request
+// with fn, e.g. to attach a group scoped logger or tenant id.
+// Like any middleware it applies to the middleware after it and the
+// handler, so place it first to make the values visible to all of them.
+//
+// Example:
+//
+//	protected := xmux.Use(userGroup, xmux.WithContext(withTenant), authenticate)
+func WithContext(fn func(ctx context.Context) context.Context) Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, bind func(params any) error) (any, error) {
+			return next(fn(ctx), bind)
+		}
+	}
+}
+
+// WithGroupContext returns a Binder whose routes run with their request
+// context derived by fn. It is shorthand for Use(binder, WithContext(fn)):
+// middleware added to the result with Use wraps it, and so runs before
+// fn is applied.
+//
+// Example:
+//
+//	admin := xmux.WithGroupContext(adminGroup, func(ctx context.Context) context.Context {
+//	    return context.WithValue(ctx, loggerKey{}, adminLogger)
+//	})
+func WithGroupContext(binder Binder, fn func(ctx context.Context) context.Context) Binder {
+	return Use(binder, WithContext(fn))
+}
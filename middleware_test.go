@@ -0,0 +1,95 @@
+package xmux
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// recorder records the entry and exit of named middleware.
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) middleware(name string) Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, bind func(params any) error) (any, error) {
+			r.events = append(r.events, name+" in")
+			defer func() { r.events = append(r.events, name+" out") }()
+			return next(ctx, bind)
+		}
+	}
+}
+
+// bindRoutes binds binder and returns its Apis by path.
+func bindRoutes(t *testing.T, binder Binder) map[string]Api {
+	t.Helper()
+	apis := make(map[string]Api)
+	err := binder.Bind(controllerFunc(func(method string, path string, api Api, options ...map[string]string) {
+		apis[path] = api
+	}), func(any) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return apis
+}
+
+func invoke(t *testing.T, api Api) {
+	t.Helper()
+	if _, err := api.Invoke(context.Background(), func(params any) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	rec := &recorder{}
+	group := binderFunc(func(controller Controller, bind func(service any) error) error {
+		Register(routerOf(controller), http.MethodGet, "/ping", func(ctx context.Context, params *struct{}) (string, error) {
+			rec.events = append(rec.events, "handler")
+			return "pong", nil
+		})
+		return nil
+	})
+	apis := bindRoutes(t, Use(Use(group, rec.middleware("a"), rec.middleware("b")), rec.middleware("c")))
+
+	invoke(t, apis["/ping"])
+	want := []string{"c in", "a in", "b in", "handler", "b out", "a out", "c out"}
+	if !reflect.DeepEqual(rec.events, want) {
+		t.Errorf("order = %v, want %v", rec.events, want)
+	}
+}
+
+// routerOf returns a Router registering routes with controller.
+func routerOf(controller Controller) Router {
+	return registerFunc(controller.Handle)
+}
+
+func TestRouteMiddleware(t *testing.T) {
+	rec := &recorder{}
+	handler := func(ctx context.Context, params *struct{}) (string, error) {
+		rec.events = append(rec.events, "handler")
+		return "", nil
+	}
+	group := binderFunc(func(controller Controller, bind func(service any) error) error {
+		r := routerOf(controller)
+		Register(r, http.MethodGet, "/users/:id", handler)
+		Register(WithMiddleware(r, rec.middleware("route")), http.MethodDelete, "/users/:id/delete", handler)
+		Register(r, http.MethodPut, "/users/:id/put", handler)
+		return nil
+	})
+	apis := bindRoutes(t, Use(group, rec.middleware("group")))
+
+	invoke(t, apis["/users/:id/delete"])
+	want := []string{"group in", "route in", "handler", "route out", "group out"}
+	if !reflect.DeepEqual(rec.events, want) {
+		t.Errorf("guarded route: order = %v, want %v", rec.events, want)
+	}
+	for _, path := range []string{"/users/:id", "/users/:id/put"} {
+		rec.events = nil
+		invoke(t, apis[path])
+		if want := []string{"group in", "handler", "group out"}; !reflect.DeepEqual(rec.events, want) {
+			t.Errorf("sibling %s: order = %v, want %v", path, rec.events, want)
+		}
+	}
+}
@@ -17,8 +17,11 @@ package xmux
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"runtime"
+	"strings"
 	"sync"
 )
 
@@ -155,27 +158,43 @@ func Register[Params any, Response any](
 	fn func(ctx context.Context, params *Params) (Response, error),
 	options ...map[string]string,
 ) {
-	router.Register(method, path, function[Params, Response](fn), options...)
+	router.Register(method, path, NewHandler(fn), options...)
+}
+
+// NewHandler returns the Api that Register builds for fn, so handlers can
+// be created apart from their registration: held in a registry or route
+// table, wrapped with Chain, and registered later with Router.Register.
+//
+// Example:
+//
+//	getUser := xmux.Chain(xmux.NewHandler(svc.GetUser), audit)
+//	r.Register(http.MethodGet, "/users/:id", getUser)
+func NewHandler[Params any, Response any](fn func(ctx context.Context, params *Params) (Response, error)) Api {
+	return function[Params, Response](fn)
 }
 
 // MergeOptions merges multiple option maps into a single map.
 // Useful for combining route-level, group-level, and global options.
 //
+// Options are ordered from the most general to the most specific: adapters
+// receive the options of outer decorators (e.g. VersionedGroup) first,
+// then group options, then route options. Merging in ascending order lets
+// the most specific option win, as adapters do.
+//
 // Parameters:
-//   - options: slice of option maps to merge
-//   - desc: if true, merge in descending order (later options override earlier)
-//     if false, merge in ascending order (earlier options override later)
+//   - options: slice of option maps to merge; nil maps are ignored
+//   - desc: if false, merge in ascending order (later options override earlier)
+//     if true, merge in descending order (earlier options override later)
 //
 // Returns:
 //   - merged option map
 //
 // Example:
 //
-//	globalOpts := map[string]string{"timeout": "30s"}
-//	groupOpts := map[string]string{"prefix": "/api"}
-//	routeOpts := map[string]string{"middleware": "auth"}
-//	merged := xmux.MergeOptions([]map[string]string{globalOpts, groupOpts, routeOpts}, true)
-//	// Result: {"timeout": "30s", "prefix": "/api", "middleware": "auth"}
+//	groupOpts := map[string]string{"prefix": "/api", "timeout": "30s"}
+//	routeOpts := map[string]string{"timeout": "5s"}
+//	merged := xmux.MergeOptions([]map[string]string{groupOpts, routeOpts}, false)
+//	// Result: {"prefix": "/api", "timeout": "5s"}
 func MergeOptions(options []map[string]string, desc bool) map[string]string {
 	opt := make(map[string]string)
 	for i := 0; i < len(options); i++ {
@@ -190,6 +209,25 @@ func MergeOptions(options []map[string]string, desc bool) map[string]string {
 	return opt
 }
 
+// joinOptions concatenates option lists, most general first, into a new
+// slice, so appending never writes to the backing array of a list shared
+// between routes. Nil maps are dropped.
+func joinOptions(lists ...[]map[string]string) []map[string]string {
+	n := 0
+	for _, list := range lists {
+		n += len(list)
+	}
+	joined := make([]map[string]string, 0, n)
+	for _, list := range lists {
+		for _, o := range list {
+			if o != nil {
+				joined = append(joined, o)
+			}
+		}
+	}
+	return joined
+}
+
 // serviceGroup represents a group of routes that share a common service.
 // It enables dependency injection for a specific service type and allows
 // registering multiple handlers that use the same service instance.
@@ -222,7 +260,7 @@ func (g serviceGroup[Service]) Bind(controller Controller, bind func(any) error)
 		controller.Handle(method, path, serviceApi[Service]{
 			Api:  api,
 			impl: s,
-		}, append(g.options, options...)...)
+		}, joinOptions(g.options, options)...)
 	}), s)
 	return
 }
@@ -287,6 +325,10 @@ type Groups interface {
 type groups struct {
 	mu     sync.Mutex
 	groups []Binder
+
+	// workers bounds the groups bound concurrently; Bind is sequential
+	// if it is 1 or less
+	workers int
 }
 
 // NewGroups creates a new Groups instance with the provided initial groups.
@@ -330,6 +372,9 @@ func (g *groups) Register(groups ...Binder) Groups {
 
 // Bind injects dependencies and binds all registered groups.
 // This method is thread-safe and can be called concurrently.
+// A route with the same method and path as an earlier one, up to the
+// names of path parameters, is not passed to the controller; Bind then
+// fails with an error wrapping ErrRouteConflict that lists them.
 //
 // Parameters:
 //   - controller: the framework controller that handles requests
@@ -355,10 +400,182 @@ func (g *groups) Bind(controller Controller, bind func(service any) error) (err
 	g.mu.Lock()
 	gs := append(make([]Binder, 0, len(g.groups)), g.groups...)
 	g.mu.Unlock()
-	for _, group := range gs {
-		if err = group.Bind(controller, bind); err != nil {
-			return
+	routes := &routeSet{next: controller, seen: make(map[string]routeSeen)}
+	if g.workers > 1 {
+		_, safe := controller.(ConcurrentSafe)
+		err = bindParallel(routes, bind, gs, g.workers, !safe)
+	} else {
+		for _, group := range gs {
+			if err = group.Bind(routes, bind); err != nil {
+				break
+			}
 		}
 	}
+	if err == nil {
+		err = routes.err()
+	}
 	return
 }
+
+// ErrRouteConflict is wrapped by the error Groups.Bind returns when two
+// routes have the same method and path.
+var ErrRouteConflict = errors.New("xmux: route conflict")
+
+// routeSet is a Controller passing routes on to next unless a route with
+// the same method and path, up to the names of path parameters, was
+// passed before. Conflicting routes are recorded instead, so binding
+// fails with a descriptive error rather than a framework panic.
+type routeSet struct {
+	mu        sync.Mutex
+	next      Controller
+	seen      map[string]routeSeen
+	conflicts []string
+}
+
+// routeSeen identifies the first route registered for a method and path.
+type routeSeen struct {
+	path string
+	name string
+}
+
+// Handle implements the Controller interface.
+func (s *routeSet) Handle(method string, path string, api Api, options ...map[string]string) {
+	key := method + " " + conflictKey(path)
+	s.mu.Lock()
+	first, conflict := s.seen[key]
+	if conflict {
+		s.conflicts = append(s.conflicts, fmt.Sprintf("%s %s (%s) conflicts with %s %s (%s)",
+			method, path, api.Name(), method, first.path, first.name))
+	} else {
+		s.seen[key] = routeSeen{path: path, name: api.Name()}
+	}
+	s.mu.Unlock()
+	if !conflict {
+		s.next.Handle(method, path, api, options...)
+	}
+}
+
+// err returns the conflicts found, or nil.
+func (s *routeSet) err() error {
+	if len(s.conflicts) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrRouteConflict, strings.Join(s.conflicts, "; "))
+}
+
+// conflictKey normalizes path for conflict detection: a trailing slash is
+// dropped, and parameters in the ":name" and "{name}" syntax, and
+// catch-all "*name" segments, lose their names.
+func conflictKey(path string) string {
+	segments := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"), strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			segments[i] = ":"
+		case strings.HasPrefix(segment, "*"):
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// ParallelGroups creates Groups binding up to workers groups
+// concurrently, so applications with many groups and slow dependency
+// resolution start faster. The bind function must be safe for concurrent
+// use. Routes are passed to the controller one at a time, unless it
+// implements ConcurrentSafe, but in no particular order across groups.
+// Every group is bound even if others fail; their errors are returned as
+// GroupErrors, in group order.
+//
+// Example:
+//
+//	groups := xmux.ParallelGroups(runtime.NumCPU(), userGroup, orderGroup, productGroup)
+func ParallelGroups(workers int, gs ...Binder) Groups {
+	return &groups{groups: append(make([]Binder, 0, len(gs)), gs...), workers: workers}
+}
+
+// ConcurrentSafe is implemented by controllers whose Handle may be called
+// concurrently, so ParallelGroups does not serialize the calls.
+type ConcurrentSafe interface {
+	ConcurrentSafe()
+}
+
+// GroupErrors lists the errors of the groups ParallelGroups failed to
+// bind.
+type GroupErrors []error
+
+// Error implements the error interface.
+func (e GroupErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors, for errors.Is and errors.As on go1.20 and
+// later.
+func (e GroupErrors) Unwrap() []error {
+	return e
+}
+
+// Is reports whether any of the errors matches target, so errors.Is
+// sees them on every Go version.
+func (e GroupErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors matching target, so errors.As sees
+// them on every Go version.
+func (e GroupErrors) As(target any) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// bindParallel binds gs with at most workers at a time, serializing
+// the calls to controller if serialize is set.
+func bindParallel(controller Controller, bind func(service any) error, gs []Binder, workers int, serialize bool) error {
+	if serialize {
+		var mu sync.Mutex
+		next := controller
+		controller = controllerFunc(func(method string, path string, api Api, options ...map[string]string) {
+			mu.Lock()
+			defer mu.Unlock()
+			next.Handle(method, path, api, options...)
+		})
+	}
+	errs := make([]error, len(gs))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, group := range gs {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, group Binder) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = group.Bind(controller, bind)
+		}(i, group)
+	}
+	wg.Wait()
+	var failed GroupErrors
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if failed != nil {
+		return failed
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package xmux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var errGroup = errors.New("group failed")
+
+func TestGroupErrors(t *testing.T) {
+	failing := func(err error) Binder {
+		return binderFunc(func(Controller, func(any) error) error { return err })
+	}
+	ok := failing(nil)
+	err := ParallelGroups(4, ok, failing(fmt.Errorf("users: %w", errGroup)), ok, failing(NewError(http.StatusConflict, "orders"))).
+		Bind(controllerFunc(func(string, string, Api, ...map[string]string) {}), func(any) error { return nil })
+
+	var groupErrs GroupErrors
+	if !errors.As(err, &groupErrs) || len(groupErrs) != 2 {
+		t.Fatalf("err = %v, want two GroupErrors", err)
+	}
+	// Is and As are implemented directly, so this holds on go1.18 too
+	if !groupErrs.Is(errGroup) || !errors.Is(err, errGroup) {
+		t.Error("errors.Is does not find a group error")
+	}
+	var httpErr *HTTPError
+	if !groupErrs.As(&httpErr) || httpErr.Status != http.StatusConflict {
+		t.Errorf("errors.As does not find the HTTPError of a group, got %v", httpErr)
+	}
+	if groupErrs.Is(context.Canceled) {
+		t.Error("Is matches an error no group returned")
+	}
+}
+
+// benchmarkGroups binds n groups of ten routes with ParallelGroups.
+func benchmarkGroups(b *testing.B, n int, fail bool) {
+	gs := make([]Binder, n)
+	for i := range gs {
+		prefix := fmt.Sprintf("/g%d", i)
+		gs[i] = binderFunc(func(controller Controller, bind func(service any) error) error {
+			for j := 0; j < 10; j++ {
+				controller.Handle(http.MethodGet, fmt.Sprintf("%s/r%d", prefix, j), NewHandler(func(ctx context.Context, params *struct{}) (string, error) {
+					return "", nil
+				}))
+			}
+			if fail {
+				return errGroup
+			}
+			return nil
+		})
+	}
+	controller := controllerFunc(func(string, string, Api, ...map[string]string) {})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := ParallelGroups(8, gs...).Bind(controller, func(any) error { return nil })
+		if fail != (err != nil) {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParallelGroups(b *testing.B) {
+	b.Run("bind", func(b *testing.B) { benchmarkGroups(b, 50, false) })
+	b.Run("errors", func(b *testing.B) { benchmarkGroups(b, 50, true) })
+}
+
+func TestRouteConflict(t *testing.T) {
+	route := func(path string) Binder {
+		return binderFunc(func(controller Controller, bind func(service any) error) error {
+			Register(routerOf(controller), http.MethodGet, path, func(ctx context.Context, params *struct{}) (string, error) {
+				return path, nil
+			})
+			return nil
+		})
+	}
+	var handled []string
+	controller := controllerFunc(func(method string, path string, api Api, options ...map[string]string) {
+		handled = append(handled, method+" "+path)
+	})
+
+	err := NewGroups(route("/users/:id"), route("/orders/:id"), route("/users/{uid}/")).Bind(controller, func(any) error { return nil })
+	if !errors.Is(err, ErrRouteConflict) {
+		t.Fatalf("err = %v, want ErrRouteConflict", err)
+	}
+	if !strings.Contains(err.Error(), "/users/{uid}/") || !strings.Contains(err.Error(), "/users/:id") {
+		t.Errorf("err = %v, want both conflicting paths listed", err)
+	}
+	if want := []string{"GET /users/:id", "GET /orders/:id"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled %v, want only the first of the conflicting routes: %v", handled, want)
+	}
+}
@@ -0,0 +1,55 @@
+package xmux
+
+import "encoding/json"
+
+// Optional is a value that tracks whether it was present in a request
+// body, so handlers can tell "set to the zero value" from "not provided".
+// It enables PATCH semantics: apply only the fields that are Set.
+//
+// A key present in the JSON body sets it, including an explicit null,
+// which leaves Value at its zero value. A missing key leaves it unset.
+//
+// Example:
+//
+//	type UpdateUserRequest struct {
+//	    ID    string                 `json:"id"`
+//	    Email xmux.Optional[string] `json:"email"`
+//	}
+//	if email, ok := req.Email.Get(); ok {
+//	    user.Email = email
+//	}
+type Optional[T any] struct {
+	Value T
+	Set   bool
+}
+
+// Some returns an Optional set to value.
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{Value: value, Set: true}
+}
+
+// Get returns the value and whether it is set.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Set
+}
+
+// MarshalJSON encodes the value, or null if it is not set.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+// UnmarshalJSON marks the value as set and decodes it.
+// It is only called for keys present in the input.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	var value T
+	if string(data) != "null" {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	}
+	o.Value, o.Set = value, true
+	return nil
+}
@@ -0,0 +1,82 @@
+package xmux
+
+import (
+	"context"
+	"fmt"
+)
+
+// PageParams holds the pagination parameters of a list request, bound
+// from the limit and offset query parameters. Embed it in params structs
+// so Paginate can bound the page size.
+type PageParams struct {
+	Limit  int `json:"-" query:"limit"`
+	Offset int `json:"-" query:"offset"`
+}
+
+// Pagination returns p, so params embedding PageParams implement
+// Pageable.
+func (p *PageParams) Pagination() *PageParams {
+	return p
+}
+
+// Pageable is implemented by params embedding PageParams.
+type Pageable interface {
+	Pagination() *PageParams
+}
+
+// PageLimits bounds the page size of list requests, see Paginate.
+type PageLimits struct {
+	// Default is the limit of requests without one
+	Default int
+
+	// Max is the largest limit; zero means no maximum
+	Max int
+
+	// Reject fails limits above Max with a BindError instead of
+	// clamping them to Max
+	Reject bool
+}
+
+// Apply sets the default limit of p and checks it against l. A negative
+// limit or offset is always a BindError.
+func (l PageLimits) Apply(p *PageParams) error {
+	if p.Limit < 0 {
+		return &BindError{Type: "query", Field: "limit", Err: fmt.Errorf("must not be negative")}
+	}
+	if p.Offset < 0 {
+		return &BindError{Type: "query", Field: "offset", Err: fmt.Errorf("must not be negative")}
+	}
+	if p.Limit == 0 {
+		p.Limit = l.Default
+	}
+	if l.Max > 0 && p.Limit > l.Max {
+		if l.Reject {
+			return &BindError{Type: "query", Field: "limit", Err: fmt.Errorf("must be at most %d", l.Max)}
+		}
+		p.Limit = l.Max
+	}
+	return nil
+}
+
+// Paginate returns a middleware applying limits to Pageable params once
+// they are bound, so handlers receive a limit in range and need no
+// defaults of their own. Other params are not affected.
+//
+// Example:
+//
+//	xmux.Use(group, xmux.Paginate(xmux.PageLimits{Default: 10, Max: 100}))
+func Paginate(limits PageLimits) Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, bind func(params any) error) (any, error) {
+			return next(ctx, func(params any) error {
+				if err := bind(params); err != nil {
+					return err
+				}
+				if p, ok := params.(Pageable); ok {
+					return limits.Apply(p.Pagination())
+				}
+				return nil
+			})
+		}
+	}
+}
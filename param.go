@@ -0,0 +1,322 @@
+package xmux
+
+import (
+	"context"
+	"encoding/json"
+	"go/token"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// pathParamFunction is the Api of a handler taking a single path parameter.
+// Its params type is a struct synthesized with one field tagged
+// `path:"<name>"`, so adapters bind it with their regular path binding.
+type pathParamFunction[P any, Response any] struct {
+	fn     func(context.Context, P) (Response, error)
+	params reflect.Type
+}
+
+// newPathParamFunction synthesizes the params struct for the path
+// parameter name.
+func newPathParamFunction[P any, Response any](name string, fn func(context.Context, P) (Response, error)) pathParamFunction[P, Response] {
+	return pathParamFunction[P, Response]{
+		fn: fn,
+		params: reflect.StructOf([]reflect.StructField{{
+			Name: exportedName(name),
+			Type: reflect.TypeOf((*P)(nil)).Elem(),
+			Tag:  reflect.StructTag(`path:"` + name + `" query:"-" json:"-"`),
+		}}),
+	}
+}
+
+// exportedName returns name as an exported Go identifier for use as the
+// synthesized field name, so bind errors refer to the parameter.
+func exportedName(name string) string {
+	if name == "" {
+		return "Value"
+	}
+	exported := strings.ToUpper(name[:1]) + name[1:]
+	if !token.IsIdentifier(exported) || !token.IsExported(exported) {
+		return "Value"
+	}
+	return exported
+}
+
+// Invoke binds the path parameter and calls the function with its value.
+func (h pathParamFunction[P, Response]) Invoke(ctx context.Context, unmarshal func(params any) error) (any, error) {
+	params := reflect.New(h.params)
+	if err := unmarshal(params.Interface()); err != nil {
+		return nil, err
+	}
+	return h.fn(ctx, params.Elem().Field(0).Interface().(P))
+}
+
+// Params returns a zero value of the synthesized params struct.
+func (h pathParamFunction[P, Response]) Params() any {
+	return reflect.Zero(h.params).Interface()
+}
+
+// Response returns a zero value of the Response type.
+func (h pathParamFunction[P, Response]) Response() any {
+	var zero Response
+	return zero
+}
+
+// Function returns the underlying function.
+func (h pathParamFunction[P, Response]) Function() any {
+	return h.fn
+}
+
+func (h pathParamFunction[P, Response]) Name() string {
+	return runtime.FuncForPC(reflect.ValueOf(h.fn).Pointer()).Name()
+}
+
+func (h pathParamFunction[P, Response]) Service() (any, reflect.Type) {
+	return nil, nil
+}
+
+// RegisterPathParam registers a handler that receives a single path
+// parameter directly instead of a params struct. The value is converted
+// to P by the adapter's path binding, as for a struct field tagged
+// `path:"<name>"`.
+//
+// Type parameters:
+//   - P: the path parameter type (string, int, ...)
+//   - Response: the response data type
+//
+// Parameters:
+//   - router: the framework router (implements Router interface)
+//   - method: HTTP method (GET, POST, PUT, DELETE, etc.)
+//   - path: URL path pattern containing the parameter (e.g., "/users/:id")
+//   - name: the path parameter name (e.g., "id")
+//   - fn: the business logic function to execute
+//   - options: optional route configuration
+//
+// Example:
+//
+//	func GetUser(ctx context.Context, id int64) (*UserResp, error) { ... }
+//	xmux.RegisterPathParam(router, http.MethodGet, "/users/:id", "id", GetUser)
+func RegisterPathParam[P any, Response any](
+	router Router,
+	method string,
+	path string,
+	name string,
+	fn func(ctx context.Context, param P) (Response, error),
+	options ...map[string]string,
+) {
+	router.Register(method, path, newPathParamFunction(name, fn), options...)
+}
+
+// noParamsFunction is the Api of a handler without params.
+// Invoke never calls the bind function, so no binding work is done.
+type noParamsFunction[Response any] func(context.Context) (Response, error)
+
+// Invoke calls the function without binding.
+func (h noParamsFunction[Response]) Invoke(ctx context.Context, _ func(params any) error) (any, error) {
+	return h(ctx)
+}
+
+// Params returns an empty struct.
+func (h noParamsFunction[Response]) Params() any {
+	return struct{}{}
+}
+
+// Response returns a zero value of the Response type.
+func (h noParamsFunction[Response]) Response() any {
+	var zero Response
+	return zero
+}
+
+// Function returns the underlying function.
+func (h noParamsFunction[Response]) Function() any {
+	return h
+}
+
+func (h noParamsFunction[Response]) Name() string {
+	return runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+}
+
+func (h noParamsFunction[Response]) Service() (any, reflect.Type) {
+	return nil, nil
+}
+
+// RegisterNoParams registers a handler that takes no params, avoiding an
+// empty params struct. The request is not bound at all.
+//
+// Example:
+//
+//	func ListProducts(ctx context.Context) ([]*Product, error) { ... }
+//	xmux.RegisterNoParams(router, http.MethodGet, "/products", ListProducts)
+func RegisterNoParams[Response any](
+	router Router,
+	method string,
+	path string,
+	fn func(ctx context.Context) (Response, error),
+	options ...map[string]string,
+) {
+	router.Register(method, path, noParamsFunction[Response](fn), options...)
+}
+
+// NoContent is the result of handlers registered with RegisterNoContent.
+// Adapters respond with 204 No Content and no body.
+type NoContent struct{}
+
+// StatusCode returns 204 No Content.
+func (NoContent) StatusCode() int {
+	return http.StatusNoContent
+}
+
+// noContentFunction is the Api of a handler without a response body.
+type noContentFunction[Params any] func(context.Context, *Params) error
+
+// Invoke binds params and calls the function, returning NoContent on success.
+func (h noContentFunction[Params]) Invoke(ctx context.Context, unmarshal func(params any) error) (any, error) {
+	var params Params
+	if err := unmarshal(&params); err != nil {
+		return nil, err
+	}
+	if err := h(ctx, &params); err != nil {
+		return nil, err
+	}
+	return NoContent{}, nil
+}
+
+// Params returns a zero value of the Params type.
+func (h noContentFunction[Params]) Params() any {
+	var zero Params
+	return zero
+}
+
+// Response returns NoContent.
+func (h noContentFunction[Params]) Response() any {
+	return NoContent{}
+}
+
+// Function returns the underlying function.
+func (h noContentFunction[Params]) Function() any {
+	return h
+}
+
+func (h noContentFunction[Params]) Name() string {
+	return runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+}
+
+func (h noContentFunction[Params]) Service() (any, reflect.Type) {
+	return nil, nil
+}
+
+// RegisterNoContent registers a handler that only acts and returns no
+// body. On success adapters respond with 204 No Content; errors are
+// handled as for any route.
+//
+// Example:
+//
+//	func DeleteUser(ctx context.Context, req *DeleteUserRequest) error { ... }
+//	xmux.RegisterNoContent(router, http.MethodDelete, "/users/:id", DeleteUser)
+func RegisterNoContent[Params any](
+	router Router,
+	method string,
+	path string,
+	fn func(ctx context.Context, params *Params) error,
+	options ...map[string]string,
+) {
+	router.Register(method, path, noContentFunction[Params](fn), options...)
+}
+
+// Created is the result of a handler creating a resource, as returned by
+// handlers registered with RegisterCreated. Adapters respond with 201
+// Created, set the Location header to Path and encode Value as the body.
+type Created[T any] struct {
+	// Value is the created resource
+	Value T
+
+	// Path is the canonical path of the resource (e.g., "/api/users/42")
+	Path string
+}
+
+// StatusCode returns 201 Created.
+func (Created[T]) StatusCode() int {
+	return http.StatusCreated
+}
+
+// Location returns the path set as the Location header.
+func (c Created[T]) Location() string {
+	return c.Path
+}
+
+// Body returns Value, the encoded body.
+func (c Created[T]) Body() any {
+	return c.Value
+}
+
+// MarshalJSON encodes Value, so the wrapper does not show in the body.
+func (c Created[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Value)
+}
+
+// createdFunction is the Api of a handler creating a resource.
+type createdFunction[Params any, Response any] struct {
+	fn       func(context.Context, *Params) (Response, error)
+	location func(Response) string
+}
+
+// Invoke binds params and calls the function, wrapping its result in
+// Created with the path computed by location.
+func (h createdFunction[Params, Response]) Invoke(ctx context.Context, unmarshal func(params any) error) (any, error) {
+	var params Params
+	if err := unmarshal(&params); err != nil {
+		return nil, err
+	}
+	resp, err := h.fn(ctx, &params)
+	if err != nil {
+		return nil, err
+	}
+	return Created[Response]{Value: resp, Path: h.location(resp)}, nil
+}
+
+// Params returns a zero value of the Params type.
+func (h createdFunction[Params, Response]) Params() any {
+	var zero Params
+	return zero
+}
+
+// Response returns a zero value of the Response type, the encoded body.
+func (h createdFunction[Params, Response]) Response() any {
+	var zero Response
+	return zero
+}
+
+// Function returns the underlying function.
+func (h createdFunction[Params, Response]) Function() any {
+	return h.fn
+}
+
+func (h createdFunction[Params, Response]) Name() string {
+	return runtime.FuncForPC(reflect.ValueOf(h.fn).Pointer()).Name()
+}
+
+func (h createdFunction[Params, Response]) Service() (any, reflect.Type) {
+	return nil, nil
+}
+
+// RegisterCreated registers a handler creating a resource. On success
+// adapters respond with 201 Created, the response as body, and a
+// Location header computed from the response by location.
+//
+// Example:
+//
+//	xmux.RegisterCreated(router, http.MethodPost, "/users", CreateUser, func(user *UserResp) string {
+//	    return "/users/" + user.ID
+//	})
+func RegisterCreated[Params any, Response any](
+	router Router,
+	method string,
+	path string,
+	fn func(ctx context.Context, params *Params) (Response, error),
+	location func(resp Response) string,
+	options ...map[string]string,
+) {
+	router.Register(method, path, createdFunction[Params, Response]{fn: fn, location: location}, options...)
+}
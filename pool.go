@@ -0,0 +1,54 @@
+package xmux
+
+import (
+	"context"
+	"sync"
+)
+
+// pooledFunction is a function Api that borrows its params from a pool
+// instead of allocating them for every request.
+type pooledFunction[Params any, Response any] struct {
+	function[Params, Response]
+	pool *sync.Pool
+}
+
+// Invoke borrows params from the pool, binds and executes the function,
+// and returns the params to the pool after resetting them to zero.
+func (h pooledFunction[Params, Response]) Invoke(ctx context.Context, unmarshal func(params any) error) (ret any, err error) {
+	params := h.pool.Get().(*Params)
+	defer func() {
+		var zero Params
+		*params = zero
+		h.pool.Put(params)
+	}()
+	if err = unmarshal(params); err != nil {
+		return
+	}
+	return h.function(ctx, params)
+}
+
+// RegisterPooled is like Register but reuses params values across
+// requests through a sync.Pool, reducing allocations on hot routes.
+// Params are reset to their zero value before being returned to the pool.
+//
+// The handler must not retain params, or anything referencing it, after
+// it returns: the value is handed to a later request. Response values
+// must not alias params for the same reason.
+//
+// Example:
+//
+//	xmux.RegisterPooled(router, http.MethodGet, "/search", svc.Search)
+func RegisterPooled[Params any, Response any](
+	router Router,
+	method string,
+	path string,
+	fn func(ctx context.Context, params *Params) (Response, error),
+	options ...map[string]string,
+) {
+	router.Register(method, path, pooledFunction[Params, Response]{
+		function: fn,
+		pool: &sync.Pool{New: func() any {
+			return new(Params)
+		}},
+	}, options...)
+}
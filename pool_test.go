@@ -0,0 +1,66 @@
+package xmux
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+type searchParams struct {
+	Query   string
+	Filters [8]string
+	Limit   int
+}
+
+func search(ctx context.Context, params *searchParams) (int, error) {
+	return params.Limit, nil
+}
+
+// apiOf returns the Api registered by register.
+func apiOf(register func(r Router)) Api {
+	var api Api
+	register(registerFunc(func(method string, path string, a Api, options ...map[string]string) {
+		api = a
+	}))
+	return api
+}
+
+func TestRegisterPooledResetsParams(t *testing.T) {
+	api := apiOf(func(r Router) { RegisterPooled(r, http.MethodGet, "/search", search) })
+	bindLimit := func(limit int) func(params any) error {
+		return func(params any) error {
+			if limit > 0 {
+				params.(*searchParams).Limit = limit
+			}
+			return nil
+		}
+	}
+	if got, err := api.Invoke(context.Background(), bindLimit(5)); err != nil || got != 5 {
+		t.Fatalf("Invoke = %v, %v; want 5", got, err)
+	}
+	if got, err := api.Invoke(context.Background(), bindLimit(0)); err != nil || got != 0 {
+		t.Errorf("Invoke = %v, %v; want params reset to zero", got, err)
+	}
+}
+
+func BenchmarkRegisterPooled(b *testing.B) {
+	bind := func(params any) error {
+		params.(*searchParams).Limit = 10
+		return nil
+	}
+	ctx := context.Background()
+	for name, register := range map[string]func(r Router){
+		"pooled":    func(r Router) { RegisterPooled(r, http.MethodGet, "/search", search) },
+		"allocated": func(r Router) { Register(r, http.MethodGet, "/search", search) },
+	} {
+		api := apiOf(register)
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := api.Invoke(ctx, bind); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
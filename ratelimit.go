@@ -0,0 +1,155 @@
+package xmux
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrTooManyRequests is returned when a client exceeds its rate limit.
+var ErrTooManyRequests = NewError(http.StatusTooManyRequests, "")
+
+// ErrUnknownClient is returned by RateLimit for requests without a client
+// IP, which cannot be told apart from each other.
+var ErrUnknownClient = NewError(http.StatusForbidden, "client address unknown")
+
+// ClientIP returns the client IP of the request stored in ctx.
+// It is derived from RequestInfo.RemoteAddr; deployments behind a proxy
+// should rewrite RemoteAddr from trusted forwarding headers.
+func ClientIP(ctx context.Context) string {
+	info, ok := RequestInfoFromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(info.RemoteAddr)
+	if err != nil {
+		return info.RemoteAddr
+	}
+	return host
+}
+
+const (
+	// rateLimitShards is the number of independently locked client maps
+	rateLimitShards = 32
+
+	// rateLimitSweep is the interval at which a shard evicts idle clients
+	rateLimitSweep = time.Minute
+)
+
+// rateClient is the limiter of a single client and its last use.
+type rateClient struct {
+	limiter *rate.Limiter
+	last    time.Time
+}
+
+// rateShard holds the clients whose key hashes to it.
+type rateShard struct {
+	mu      sync.Mutex
+	swept   time.Time
+	clients map[string]*rateClient
+}
+
+// rateLimiter keeps one rate.Limiter per client IP, spread over shards so
+// clients contend for a lock only with clients of the same shard.
+type rateLimiter struct {
+	limit  rate.Limit
+	burst  int
+	idle   time.Duration
+	shards [rateLimitShards]rateShard
+}
+
+// reserve reserves a token for key at now.
+func (l *rateLimiter) reserve(key string, now time.Time) *rate.Reservation {
+	shard := &l.shards[shardOf(key)]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.sweep(now, l.idle)
+	c, ok := shard.clients[key]
+	if !ok {
+		c = &rateClient{limiter: rate.NewLimiter(l.limit, l.burst)}
+		shard.clients[key] = c
+	}
+	c.last = now
+	return c.limiter.ReserveN(now, 1)
+}
+
+// shardOf returns the shard index of key using FNV-1a.
+func shardOf(key string) uint32 {
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	return h % rateLimitShards
+}
+
+// sweep evicts, at most once per rateLimitSweep, the clients idle long
+// enough for their limiter to have refilled completely. Such limiters are
+// indistinguishable from new ones, so memory stays bounded by the number
+// of recently active clients.
+func (s *rateShard) sweep(now time.Time, idle time.Duration) {
+	if now.Sub(s.swept) < rateLimitSweep {
+		return
+	}
+	s.swept = now
+	for key, c := range s.clients {
+		if now.Sub(c.last) >= idle {
+			delete(s.clients, key)
+		}
+	}
+}
+
+// RateLimit returns a middleware limiting each client IP (see ClientIP)
+// to rps requests per second with bursts of up to burst requests.
+// Rejected requests get ErrTooManyRequests with a Retry-After header.
+// Requests without a client IP are rejected with ErrUnknownClient rather
+// than sharing one limit.
+//
+// Each call creates an independent limiter, so attach it to the groups
+// that need it:
+//
+//	public := xmux.Use(authGroup, xmux.RateLimit(5, 10))
+func RateLimit(rps float64, burst int) Middleware {
+	if rps <= 0 {
+		panic("xmux: RateLimit requires a positive rps")
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	limiter := &rateLimiter{
+		limit: rate.Limit(rps),
+		burst: burst,
+		idle:  time.Duration(float64(burst) / rps * float64(time.Second)),
+	}
+	for i := range limiter.shards {
+		limiter.shards[i].clients = make(map[string]*rateClient)
+	}
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, bind func(params any) error) (any, error) {
+			ip := ClientIP(ctx)
+			if ip == "" {
+				return nil, ErrUnknownClient
+			}
+			now := time.Now()
+			r := limiter.reserve(ip, now)
+			if wait := r.DelayFrom(now); wait > 0 {
+				// The request is rejected, so its token is given back
+				r.CancelAt(now)
+				retry := int(math.Ceil(wait.Seconds()))
+				return nil, &HTTPError{
+					Status:  ErrTooManyRequests.Status,
+					Message: ErrTooManyRequests.Message,
+					Header:  http.Header{"Retry-After": []string{strconv.Itoa(retry)}},
+				}
+			}
+			return next(ctx, bind)
+		}
+	}
+}
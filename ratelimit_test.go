@@ -0,0 +1,69 @@
+package xmux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func invokeFrom(t *testing.T, mw Middleware, remoteAddr string) error {
+	t.Helper()
+	ctx := context.Background()
+	if remoteAddr != "" {
+		ctx = WithRequestInfo(ctx, &RequestInfo{RemoteAddr: remoteAddr})
+	}
+	_, err := mw(func(ctx context.Context, bind func(params any) error) (any, error) {
+		return nil, nil
+	})(ctx, nil)
+	return err
+}
+
+func TestRateLimit(t *testing.T) {
+	limit := RateLimit(1, 2)
+
+	for i := 0; i < 2; i++ {
+		if err := invokeFrom(t, limit, "10.0.0.1:1234"); err != nil {
+			t.Fatalf("request %d: unexpected error %v", i, err)
+		}
+	}
+	err := invokeFrom(t, limit, "10.0.0.1:5678")
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Status != http.StatusTooManyRequests {
+		t.Fatalf("got %v, want 429", err)
+	}
+	if got := httpErr.Header.Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+
+	if err := invokeFrom(t, limit, "10.0.0.2:1234"); err != nil {
+		t.Errorf("other client: unexpected error %v", err)
+	}
+}
+
+func TestRateLimitUnknownClient(t *testing.T) {
+	limit := RateLimit(100, 100)
+	if err := invokeFrom(t, limit, ""); err != ErrUnknownClient {
+		t.Fatalf("got %v, want ErrUnknownClient", err)
+	}
+}
+
+func TestRateLimitSweep(t *testing.T) {
+	l := &rateLimiter{limit: 1, burst: 1, idle: time.Second}
+	for i := range l.shards {
+		l.shards[i].clients = make(map[string]*rateClient)
+	}
+	start := time.Now()
+	l.reserve("a", start)
+	shard := &l.shards[shardOf("a")]
+
+	shard.sweep(start.Add(2*time.Second), l.idle)
+	if _, ok := shard.clients["a"]; !ok {
+		t.Fatal("idle client evicted before the sweep interval")
+	}
+	shard.sweep(start.Add(rateLimitSweep), l.idle)
+	if _, ok := shard.clients["a"]; ok {
+		t.Fatal("idle client not evicted")
+	}
+}
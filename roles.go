@@ -0,0 +1,56 @@
+package xmux
+
+import (
+	"context"
+	"strings"
+)
+
+// OptionRoles is the route option key listing the roles allowed to access
+// a route, separated by commas.
+const OptionRoles = "roles"
+
+// WithRoles returns a route option restricting access to the given roles.
+// It is enforced by the RequireRoles middleware.
+//
+// Example:
+//
+//	xmux.Register(r, http.MethodGet, "/users", svc.ListUsers, xmux.WithRoles("admin"))
+func WithRoles(roles ...string) map[string]string {
+	return map[string]string{OptionRoles: strings.Join(roles, ",")}
+}
+
+// RequireRoles returns a middleware enforcing the roles route option.
+// Routes without the option are not restricted. For restricted routes
+// the role from RoleFromContext must be one of the allowed roles,
+// otherwise ErrForbidden is returned.
+//
+// It must be installed after the authentication middleware that calls
+// WithRole, so the role is available:
+//
+//	xmux.Use(group, authenticate, xmux.RequireRoles())
+func RequireRoles() Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, bind func(params any) error) (any, error) {
+			route, _ := routeFromContext(ctx)
+			allowed := route.options[OptionRoles]
+			if allowed == "" {
+				return next(ctx, bind)
+			}
+			role, ok := RoleFromContext(ctx)
+			if !ok || !hasRole(allowed, role) {
+				return nil, ErrForbidden
+			}
+			return next(ctx, bind)
+		}
+	}
+}
+
+// hasRole reports whether role is in the comma separated allowed list.
+func hasRole(allowed string, role string) bool {
+	for _, r := range strings.Split(allowed, ",") {
+		if strings.TrimSpace(r) == role {
+			return true
+		}
+	}
+	return false
+}
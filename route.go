@@ -0,0 +1,55 @@
+package xmux
+
+import "context"
+
+// RouteDef describes a route as data, so route tables can be built
+// programmatically and registered at once with RegisterAll.
+type RouteDef struct {
+	// Method is the HTTP method (GET, POST, PUT, DELETE, etc.)
+	Method string
+
+	// Path is the URL path pattern (e.g., "/users/:id")
+	Path string
+
+	// Api is the type-safe handler to invoke
+	Api Api
+
+	// Options are the route options
+	Options []map[string]string
+}
+
+// Route creates the RouteDef of a business logic function.
+// It is the data form of Register.
+//
+// Example:
+//
+//	defs := []xmux.RouteDef{
+//	    xmux.Route(http.MethodGet, "/users", svc.ListUsers),
+//	    xmux.Route(http.MethodPost, "/users", svc.CreateUser),
+//	}
+func Route[Params any, Response any](
+	method string,
+	path string,
+	fn func(ctx context.Context, params *Params) (Response, error),
+	options ...map[string]string,
+) RouteDef {
+	return RouteDef{
+		Method:  method,
+		Path:    path,
+		Api:     NewHandler(fn),
+		Options: options,
+	}
+}
+
+// RegisterAll registers every route of defs with router, in order.
+//
+// Example:
+//
+//	xmux.ServiceGroup(func(r xmux.Router, svc *UserService) {
+//	    xmux.RegisterAll(r, userRoutes(svc)...)
+//	})
+func RegisterAll(router Router, defs ...RouteDef) {
+	for _, def := range defs {
+		router.Register(def.Method, def.Path, def.Api, def.Options...)
+	}
+}
@@ -0,0 +1,21 @@
+package xmux
+
+// OptionSparseFields is the route option key enabling ("true") the
+// fields query parameter, which selects the response fields to return.
+const OptionSparseFields = "sparse_fields"
+
+// SparseFields returns a route option letting clients select response
+// fields with a fields query parameter listing JSON names, e.g.
+// ?fields=id,email. For list responses the fields of each element are
+// selected, as are those of the elements of a page: a response
+// implementing Paginated with a single list of structs, whose other
+// fields are kept. Names unknown to the response type are rejected with 400.
+// Routes without the option ignore the parameter and return full
+// payloads.
+//
+// Example:
+//
+//	xmux.Register(r, http.MethodGet, "/users/:id", svc.GetUser, xmux.SparseFields())
+func SparseFields() map[string]string {
+	return map[string]string{OptionSparseFields: "true"}
+}
@@ -0,0 +1,43 @@
+package xmux
+
+import "time"
+
+// Event is a single Server-Sent Event.
+type Event struct {
+	// ID sets the event id, echoed by clients as Last-Event-ID on reconnect
+	ID string
+
+	// Event is the event type; empty means the default "message" type
+	Event string
+
+	// Data is the payload. Strings are sent as is, other values as JSON.
+	Data any
+
+	// Retry tells the client how long to wait before reconnecting
+	Retry time.Duration
+}
+
+// SSEResponse is a response streamed as Server-Sent Events.
+// Adapters write each event received from Events until the channel is
+// closed or the request context is done. The handler owns the channel
+// and must close it, or stop sending, once ctx is done.
+//
+// Example:
+//
+//	func (s *Service) Feed(ctx context.Context, req *FeedRequest) (*xmux.SSEResponse, error) {
+//	    events := make(chan xmux.Event)
+//	    go func() {
+//	        defer close(events)
+//	        for n := range s.subscribe(ctx, req.UserID) {
+//	            select {
+//	            case events <- xmux.Event{Event: "notification", Data: n}:
+//	            case <-ctx.Done():
+//	                return
+//	            }
+//	        }
+//	    }()
+//	    return &xmux.SSEResponse{Events: events}, nil
+//	}
+type SSEResponse struct {
+	Events <-chan Event
+}
@@ -0,0 +1,93 @@
+package xmux
+
+import "strings"
+
+// OptionVersion is the route option key holding the API version of a
+// route. Adapters echo it in the X-API-Version response header.
+const OptionVersion = "version"
+
+// OptionRaw is the route option key marking a route as raw: its path is
+// registered as is, bypassing NormalizePaths, Prefix and VersionedGroup.
+// Use it for health checks and well-known URLs that must live at the root.
+const OptionRaw = "raw"
+
+// Raw returns the route option marking a route as raw.
+//
+// Example:
+//
+//	xmux.Register(r, http.MethodGet, "/healthz", svc.Health, xmux.Raw())
+func Raw() map[string]string {
+	return map[string]string{OptionRaw: "true"}
+}
+
+// NormalizePaths returns a Binder registering every route of binder with
+// its path rewritten by normalize. Routes marked Raw are left unchanged.
+//
+// Example:
+//
+//	lower := xmux.NormalizePaths(strings.ToLower, group)
+func NormalizePaths(normalize func(path string) string, binder Binder) Binder {
+	return binderFunc(func(controller Controller, bind func(service any) error) error {
+		return binder.Bind(controllerFunc(func(method string, path string, api Api, options ...map[string]string) {
+			if MergeOptions(options, false)[OptionRaw] != "true" {
+				path = normalize(path)
+			}
+			controller.Handle(method, path, api, options...)
+		}), bind)
+	})
+}
+
+// Prefix returns a Binder registering every route of binder under the
+// path prefix. Routes marked Raw are not prefixed.
+//
+// Example:
+//
+//	admin := xmux.Prefix("/admin", adminGroup)
+func Prefix(prefix string, binder Binder) Binder {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return NormalizePaths(func(path string) string {
+		return prefix + path
+	}, binder)
+}
+
+// Mount returns a Binder registering every route of binder under the
+// path prefix with the given options, so a whole Groups can be mounted
+// at a sub-path without editing its routes. The options come first, so
+// group and route options override them. Routes marked Raw keep their
+// path. Mounts nest: the outer prefix is prepended to the inner one.
+//
+// Example:
+//
+//	admin := xmux.Mount("/admin", adminGroups, xmux.WithRoles("admin"))
+//	protected := xmux.Use(admin, authenticate, xmux.RequireRoles())
+func Mount(prefix string, binder Binder, options ...map[string]string) Binder {
+	mounted := binderFunc(func(controller Controller, bind func(service any) error) error {
+		return binder.Bind(controllerFunc(func(method string, path string, api Api, routeOptions ...map[string]string) {
+			controller.Handle(method, path, api, joinOptions(options, routeOptions)...)
+		}), bind)
+	})
+	return Prefix(prefix, mounted)
+}
+
+// VersionedGroup returns a Binder registering every route of binder
+// under "/api/{version}" with the version route option set.
+// Routes marked Raw keep their path.
+// The same group can be mounted for several versions, or each version
+// can use its own group.
+//
+// Example:
+//
+//	groups := xmux.NewGroups(
+//	    xmux.VersionedGroup("v1", userGroupV1),
+//	    xmux.VersionedGroup("v2", userGroupV2),
+//	)
+func VersionedGroup(version string, binder Binder) Binder {
+	versioned := binderFunc(func(controller Controller, bind func(service any) error) error {
+		return binder.Bind(controllerFunc(func(method string, path string, api Api, options ...map[string]string) {
+			// The version comes first, so a route or group option overrides it
+			versionOption := []map[string]string{{OptionVersion: version}}
+			controller.Handle(method, path, api, joinOptions(versionOption, options)...)
+		}), bind)
+	})
+	return Prefix("/api/"+version, versioned)
+}
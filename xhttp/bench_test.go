@@ -0,0 +1,114 @@
+package xhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Just-maple/xmux"
+)
+
+type benchParams struct {
+	ID     string   `path:"id"`
+	Query  string   `query:"q"`
+	Limit  int      `query:"limit"`
+	Active bool     `query:"active"`
+	Tags   []string `query:"tags,delimited"`
+	Token  string   `header:"X-Token"`
+}
+
+// benchRequest returns a request binding every field of benchParams.
+func benchRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/items/42?q=search&limit=10&active=yes&tags=a,b", nil)
+	r.Header.Set("X-Token", "secret")
+	return WithPathParams(r, map[string]string{"id": "42"})
+}
+
+// uncachedPlan builds the plan of t without the field cache, parsing the
+// struct tags again as binding did before the cache.
+func uncachedPlan(t reflect.Type) *BindPlan {
+	fields := &structFields{bySource: make(map[string][]boundField, len(bindSources))}
+	fields.collect(t, nil, "", "", map[reflect.Type]bool{t: true})
+	return &BindPlan{typ: t, fields: fields}
+}
+
+func BenchmarkFieldCache(b *testing.B) {
+	r := benchRequest()
+	t := reflect.TypeOf(benchParams{})
+	b.Run("cached", func(b *testing.B) {
+		PrecompileBinding(benchParams{})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var p benchParams
+			if err := CompileBind(t).Bind(r, &p); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var p benchParams
+			if err := uncachedPlan(t).Bind(r, &p); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkBindPlan(b *testing.B) {
+	r := benchRequest()
+	b.Run("compiled", func(b *testing.B) {
+		plan := CompileBind(reflect.TypeOf(benchParams{}))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var p benchParams
+			if err := plan.Bind(r, &p); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	// Without a plan the params type is resolved on every request
+	b.Run("per request", func(b *testing.B) {
+		var plan *BindPlan
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var p benchParams
+			if err := plan.Bind(r, &p); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkRequestBinder(b *testing.B) {
+	r := benchRequest()
+	h := NewHandler(http.MethodGet, "/items/:id", xmux.NewHandler(func(ctx context.Context, params *benchParams) (string, error) {
+		return params.ID, nil
+	}))
+	ctx := context.Background()
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			rb := getRequestBinder(h, r)
+			if _, err := h.api.Invoke(ctx, rb.fn); err != nil {
+				b.Fatal(err)
+			}
+			putRequestBinder(rb)
+		}
+	})
+	// A closure over the request, as adapters built before pooling
+	b.Run("closure", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			bind := func(params any) error {
+				return h.plan.bind(r, params, false)
+			}
+			if _, err := h.api.Invoke(ctx, bind); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package xhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Just-maple/xmux"
+)
+
+// pathParamsKey is the context key for path parameters.
+type pathParamsKey struct{}
+
+// WithPathParams returns a shallow copy of r carrying the path parameters
+// matched by the framework router. Adapters call it before serving a
+// Handler so that fields tagged `path` can be bound.
+func WithPathParams(r *http.Request, params map[string]string) *http.Request {
+	if len(params) == 0 {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), pathParamsKey{}, params))
+}
+
+// pathParams returns the path parameters stored in the request.
+func pathParams(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(pathParamsKey{}).(map[string]string)
+	return params
+}
+
+// BracePattern converts an xmux route pattern using ":name" segments to
+// the "{name}" syntax used by routers such as chi and gorilla/mux.
+// A catch-all "*name" segment becomes "{name:.*}", as gorilla/mux
+// expects; a bare "*" is kept for chi.
+func BracePattern(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			segments[i] = "{" + segment[1:] + "}"
+		case strings.HasPrefix(segment, "*") && len(segment) > 1:
+			segments[i] = "{" + segment[1:] + ":.*}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// WildcardName returns the name of the catch-all "*name" last segment of
+// an xmux route pattern, e.g. "filepath" for "/files/*filepath", or "".
+// The segment captures the rest of the path, without a leading slash,
+// into the path parameter of that name. Routers naming the catch-all
+// "*", such as chi, echo and fiber, register
+// strings.TrimSuffix(pattern, name) and store their "*" parameter under
+// name.
+func WildcardName(pattern string) string {
+	last := pattern[strings.LastIndex(pattern, "/")+1:]
+	if !strings.HasPrefix(last, "*") {
+		return ""
+	}
+	return last[1:]
+}
+
+// BindPlan is a binding procedure compiled once per params type.
+// It records which request parts to read, which fields receive them and
+// which converters parse them, so requests skip all tag parsing and
+// type switches.
+type BindPlan struct {
+	// typ is the params struct type the plan was compiled for
+	typ reflect.Type
+
+	// fields holds the bound fields per source
+	fields *structFields
+}
+
+// CompileBind compiles the BindPlan for paramType, as returned by
+// reflect.TypeOf(api.Params()). Pointer types are dereferenced.
+// Returns nil if paramType is not a struct, in which case only the
+// request body is bound.
+func CompileBind(paramType reflect.Type) *BindPlan {
+	for paramType != nil && paramType.Kind() == reflect.Pointer {
+		paramType = paramType.Elem()
+	}
+	if paramType == nil || paramType.Kind() != reflect.Struct {
+		return nil
+	}
+	return &BindPlan{typ: paramType, fields: structFieldsOf(paramType)}
+}
+
+// Bind populates params from the request.
+// The JSON body is decoded first, then query values, path parameters and
+// finally headers are applied, so later sources override earlier ones.
+// Bodies of unknown length, as sent with chunked transfer encoding, are
+// decoded as well. An empty body binds nothing, leaving the params zero,
+// while a truncated or invalid one is a BindError.
+// The bound values are then checked against `validate` tags, reporting
+// every failing field in an xmux.ValidationError.
+// A nil plan, or params of a different type, fall back to reflecting
+// on params at request time.
+//
+// The request part a field is bound from is inferred from its tags: the
+// body by its `json` name, and query values, path parameters and headers
+// only by a `query`, `path` or `header` tag, so a field with just a
+// `json` name cannot be set through the URL. An `in:"body"`,
+// `in:"query"`, `in:"path"` or `in:"header"` tag takes precedence over
+// inference and restricts the field to that single source; on an
+// embedded struct it applies to all of its fields. A non-embedded field
+// tagged `in:"body"` receives the whole request body instead of the
+// params struct. A params type that is a slice, or such a field of slice
+// type, takes a JSON array body; the elements are validated one by one
+// (see validateBody). A `query:"*"` map field collects the query values
+// bound to no other field (see BindQuery).
+func (p *BindPlan) Bind(r *http.Request, params any) error {
+	return p.bind(r, params, false)
+}
+
+// ErrBodyRequired is the cause of the BindError for an empty body on a
+// route with the xmux.RequireBody option.
+var ErrBodyRequired = errors.New("request body is required")
+
+// bind implements Bind; requireBody makes an empty body a BindError.
+func (p *BindPlan) bind(r *http.Request, params any, requireBody bool) error {
+	v := reflect.ValueOf(params)
+	fields := (*structFields)(nil)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		if p != nil && v.Elem().Type() == p.typ {
+			fields = p.fields
+		} else if v.Elem().Kind() == reflect.Struct {
+			fields = structFieldsOf(v.Elem().Type())
+		}
+	}
+
+	empty := r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0
+	if !empty {
+		target := params
+		if fields != nil && fields.body != nil {
+			target = fieldByIndex(v.Elem(), fields.body).Addr().Interface()
+		}
+		err := json.NewDecoder(r.Body).Decode(target)
+		switch {
+		case err == io.EOF:
+			// An empty body of unknown length, e.g. chunked, binds nothing
+			empty = true
+		case err != nil:
+			bindErr := &xmux.BindError{Type: "body", Err: err}
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				bindErr.Field = typeErr.Field
+			}
+			return bindErr
+		case fields == nil || fields.body != nil:
+			if err := validateBody(reflect.ValueOf(target)); err != nil {
+				return err
+			}
+		}
+	}
+	if empty && requireBody {
+		return &xmux.BindError{Type: "body", Err: ErrBodyRequired}
+	}
+	if fields == nil {
+		return nil
+	}
+	v = v.Elem()
+
+	if query := fields.bySource["query"]; (len(query) > 0 || fields.queryRest != nil) && r.URL.RawQuery != "" {
+		values := r.URL.Query()
+		if err := setFields(v, "query", query, func(name string) ([]string, bool) {
+			value, ok := values[name]
+			return value, ok && len(value) > 0
+		}); err != nil {
+			return err
+		}
+		fields.setQueryRest(v, values)
+	}
+	if path := fields.bySource["path"]; len(path) > 0 {
+		if params := pathParams(r); len(params) > 0 {
+			if err := setFields(v, "path", path, func(name string) ([]string, bool) {
+				value, ok := params[name]
+				return []string{value}, ok
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	if header := fields.bySource["header"]; len(header) > 0 {
+		if err := setFields(v, "header", header, func(name string) ([]string, bool) {
+			values := r.Header.Values(name)
+			return values, len(values) > 0
+		}); err != nil {
+			return err
+		}
+	}
+	return fields.validate(v)
+}
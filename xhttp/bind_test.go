@@ -0,0 +1,150 @@
+package xhttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Just-maple/xmux"
+)
+
+func TestBindJSONOnlyFieldsFromBodyOnly(t *testing.T) {
+	type params struct {
+		Role  string `json:"role"`
+		Name  string `json:"name" query:"name"`
+		Email string
+	}
+	r := httptest.NewRequest(http.MethodPost, "/x?role=admin&name=query&Email=query", strings.NewReader(`{"role":"user"}`))
+	r = WithPathParams(r, map[string]string{"role": "admin", "Email": "path"})
+
+	var p params
+	if err := CompileBind(nil).Bind(r, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Role != "user" {
+		t.Errorf("Role = %q, want the body value user", p.Role)
+	}
+	if p.Name != "query" {
+		t.Errorf("Name = %q, want the query value", p.Name)
+	}
+	if p.Email != "" {
+		t.Errorf("Email = %q, want it unbound", p.Email)
+	}
+}
+
+func TestBindInTagOptsIn(t *testing.T) {
+	type params struct {
+		Role string   `json:"role" in:"query"`
+		Tags []string `json:"tags" query:",delimited"`
+	}
+	r := httptest.NewRequest(http.MethodGet, "/x?role=admin&tags=a,b", nil)
+
+	var p params
+	if err := CompileBind(nil).Bind(r, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Role != "admin" {
+		t.Errorf("Role = %q, want admin", p.Role)
+	}
+	if len(p.Tags) != 2 || p.Tags[0] != "a" || p.Tags[1] != "b" {
+		t.Errorf("Tags = %q, want [a b]", p.Tags)
+	}
+}
+
+func TestBindBody(t *testing.T) {
+	type params struct {
+		Name string `json:"name"`
+	}
+	cases := []struct {
+		name        string
+		body        string
+		chunked     bool
+		requireBody bool
+		want        string
+		wantErr     bool
+	}{
+		{name: "empty", body: ""},
+		{name: "empty chunked", body: "", chunked: true},
+		{name: "valid", body: `{"name":"alice"}`, want: "alice"},
+		{name: "truncated", body: `{"name":"al`, wantErr: true},
+		{name: "invalid", body: `name=alice`, wantErr: true},
+		{name: "empty required", body: "", requireBody: true, wantErr: true},
+		{name: "empty chunked required", body: "", chunked: true, requireBody: true, wantErr: true},
+		{name: "valid required", body: `{"name":"alice"}`, requireBody: true, want: "alice"},
+	}
+	plan := CompileBind(reflect.TypeOf(params{}))
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tc.body))
+			if tc.chunked {
+				r.ContentLength = -1
+			}
+			var p params
+			err := plan.bind(r, &p, tc.requireBody)
+			if tc.wantErr {
+				var bindErr *xmux.BindError
+				if !errors.As(err, &bindErr) || bindErr.Type != "body" {
+					t.Fatalf("err = %v, want a body BindError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Name != tc.want {
+				t.Errorf("Name = %q, want %q", p.Name, tc.want)
+			}
+		})
+	}
+}
+
+func TestBindQueryBool(t *testing.T) {
+	type params struct {
+		Active bool `query:"active"`
+	}
+	cases := []struct {
+		query   string
+		want    bool
+		wantErr bool
+	}{
+		{query: "active=1", want: true},
+		{query: "active=true", want: true},
+		{query: "active=TRUE", want: true},
+		{query: "active=yes", want: true},
+		{query: "active=Yes", want: true},
+		{query: "active=on", want: true},
+		{query: "active", want: true},
+		{query: "active=", want: true},
+		{query: "active=0", want: false},
+		{query: "active=false", want: false},
+		{query: "active=No", want: false},
+		{query: "active=off", want: false},
+		{query: "active=OFF", want: false},
+		{query: "", want: false},
+		{query: "active=maybe", wantErr: true},
+		{query: "active=2", wantErr: true},
+	}
+	plan := CompileBind(reflect.TypeOf(params{}))
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			var p params
+			err := plan.Bind(httptest.NewRequest(http.MethodGet, "/users?"+tc.query, nil), &p)
+			if tc.wantErr {
+				var bindErr *xmux.BindError
+				if !errors.As(err, &bindErr) || bindErr.Type != "query" {
+					t.Fatalf("err = %v, want a query BindError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Active != tc.want {
+				t.Errorf("Active = %v, want %v", p.Active, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package xhttp
+
+import (
+	"net/http"
+	"time"
+)
+
+// lastModified sets the Last-Modified header to modified and reports
+// whether the request is a GET or HEAD whose If-Modified-Since is not
+// older than modified, so the response is 304 Not Modified. The times
+// are compared at the one second resolution of HTTP dates, and
+// If-Modified-Since is ignored when If-None-Match is present.
+func lastModified(w http.ResponseWriter, r *http.Request, modified time.Time) bool {
+	if modified.IsZero() {
+		return false
+	}
+	modified = modified.UTC().Truncate(time.Second)
+	w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+	if r.Method != http.MethodGet && r.Method != http.MethodHead || r.Header.Get("If-None-Match") != "" {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modified.After(since)
+}
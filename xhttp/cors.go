@@ -0,0 +1,114 @@
+package xhttp
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures the CORS middleware.
+type CORSOptions struct {
+	// AllowedOrigins lists the origins allowed to make requests.
+	// "*" allows any origin.
+	AllowedOrigins []string
+
+	// AllowedMethods lists the methods allowed in preflight requests.
+	// Defaults to GET, HEAD, POST, PUT, PATCH and DELETE.
+	AllowedMethods []string
+
+	// AllowedHeaders lists the request headers allowed in preflight requests.
+	// If empty, the headers requested by the client are allowed.
+	AllowedHeaders []string
+
+	// ExposedHeaders lists the response headers readable by the client.
+	ExposedHeaders []string
+
+	// AllowCredentials allows cookies and authorization headers.
+	// The request origin is echoed instead of "*" when set.
+	AllowCredentials bool
+
+	// MaxAge is how long preflight results may be cached.
+	MaxAge time.Duration
+}
+
+// CORS returns a net/http middleware handling cross-origin requests.
+// Preflight OPTIONS requests are answered with 204 No Content and never
+// reach the wrapped handler. Since every adapter serves a http.Handler,
+// wrapping the adapter gives the same CORS behavior for any framework:
+//
+//	handler := xhttp.CORS(xhttp.CORSOptions{AllowedOrigins: []string{"https://example.com"}})(controller)
+//	http.ListenAndServe(":8080", handler)
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+	methods := opts.AllowedMethods
+	if len(methods) == 0 {
+		methods = []string{
+			http.MethodGet, http.MethodHead, http.MethodPost,
+			http.MethodPut, http.MethodPatch, http.MethodDelete,
+		}
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(opts.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(opts.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Add("Vary", "Origin")
+			allowOrigin, ok := matchOrigin(opts, origin)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			header.Set("Access-Control-Allow-Origin", allowOrigin)
+			if opts.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			requestMethod := r.Header.Get("Access-Control-Request-Method")
+			if r.Method != http.MethodOptions || requestMethod == "" {
+				if exposeHeaders != "" {
+					header.Set("Access-Control-Expose-Headers", exposeHeaders)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			header.Set("Access-Control-Allow-Methods", allowMethods)
+			if allowHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", allowHeaders)
+			} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+				header.Set("Access-Control-Allow-Headers", requested)
+			}
+			if opts.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// matchOrigin returns the Access-Control-Allow-Origin value for origin.
+func matchOrigin(opts CORSOptions, origin string) (string, bool) {
+	for _, allowed := range opts.AllowedOrigins {
+		if allowed == "*" {
+			if opts.AllowCredentials {
+				return origin, true
+			}
+			return "*", true
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,122 @@
+package xhttp
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EncodeQuery serializes the struct v, or the struct it points to, into
+// query values, reversing BindQuery so typed clients can be built on the
+// params types of the server. Fields are named as BindQuery names them,
+// and fields holding their zero value, including nil pointers, are
+// skipped.
+//
+// Slice fields add one value per element, or a single comma separated
+// value if delimited. time.Time is written in RFC 3339, which BindQuery
+// parses first, and types implementing encoding.TextMarshaler with
+// MarshalText. The values of a `query:"*"` field are added as they are.
+//
+// Example:
+//
+//	values, err := xhttp.EncodeQuery(&ListUsersRequest{Role: []string{"admin"}, Limit: 20})
+//	req.URL.RawQuery = values.Encode()
+func EncodeQuery(v any) (url.Values, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return url.Values{}, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("xhttp: EncodeQuery of non-struct type %s", rv.Type())
+	}
+	fields := structFieldsOf(rv.Type())
+	values := make(url.Values)
+	for _, field := range fields.bySource["query"] {
+		// A non-nil pointer is encoded even if it points to a zero value
+		value, ok := lookupField(rv, field.index)
+		if !ok || value.IsZero() && rv.Type().FieldByIndex(field.index).Type.Kind() != reflect.Pointer {
+			continue
+		}
+		if !field.slice {
+			raw, err := formatValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("xhttp: encode query field %s: %w", field.field, err)
+			}
+			values.Add(field.name, raw)
+			continue
+		}
+		raw := make([]string, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			elem := value.Index(i)
+			if elem.Kind() == reflect.Pointer && elem.IsNil() {
+				continue
+			}
+			s, err := formatValue(elem)
+			if err != nil {
+				return nil, fmt.Errorf("xhttp: encode query field %s: %w", field.field, err)
+			}
+			raw = append(raw, s)
+		}
+		if field.delimited && len(raw) > 0 {
+			raw = []string{strings.Join(raw, ",")}
+		}
+		values[field.name] = append(values[field.name], raw...)
+	}
+	if fields.queryRest != nil {
+		if rest, ok := lookupField(rv, fields.queryRest); ok {
+			iter := rest.MapRange()
+			for iter.Next() {
+				name, value := iter.Key().String(), iter.Value()
+				if value.Kind() == reflect.String {
+					values.Add(name, value.String())
+					continue
+				}
+				for i := 0; i < value.Len(); i++ {
+					values.Add(name, value.Index(i).String())
+				}
+			}
+		}
+	}
+	return values, nil
+}
+
+// formatValue formats v as converterFor parses values of its type.
+func formatValue(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	switch v.Type() {
+	case timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	case durationType:
+		return time.Duration(v.Int()).String(), nil
+	}
+	if v.Type().Implements(textMarshalerType) || v.CanAddr() && reflect.PointerTo(v.Type()).Implements(textMarshalerType) {
+		if !v.Type().Implements(textMarshalerType) {
+			v = v.Addr()
+		}
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
@@ -0,0 +1,53 @@
+package xhttp
+
+import (
+	"net/http"
+
+	"github.com/Just-maple/xmux"
+)
+
+// NotFound returns the handler for requests matching no route:
+// Config.NotFoundHandler if set, otherwise one answering ErrNotFound in
+// the same JSON error shape as route errors. Adapters install it as the
+// not found handler of their router.
+//
+// Example:
+//
+//	mux.NotFound(config.NotFound().ServeHTTP)
+func (c Config) NotFound() http.Handler {
+	if c.NotFoundHandler != nil {
+		return c.NotFoundHandler
+	}
+	return c.errorHandler(ErrNotFound)
+}
+
+// MethodNotAllowed returns the handler for requests whose path matches a
+// route registered for other methods only: Config.MethodNotAllowedHandler
+// if set, otherwise one answering ErrMethodNotAllowed in the same JSON
+// error shape as route errors.
+//
+// Example:
+//
+//	mux.MethodNotAllowed(config.MethodNotAllowed().ServeHTTP)
+func (c Config) MethodNotAllowed() http.Handler {
+	if c.MethodNotAllowedHandler != nil {
+		return c.MethodNotAllowedHandler
+	}
+	return c.errorHandler(ErrMethodNotAllowed)
+}
+
+// errorHandler returns a handler answering err like a route would,
+// with the request id and Config.Version headers set.
+func (c Config) errorHandler(err error) http.Handler {
+	h := &Handler{config: c.withDefaults()}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(enrichContext(r))
+		if id, ok := xmux.RequestIDFromContext(r.Context()); ok {
+			w.Header().Set(HeaderRequestID, id)
+		}
+		if c.Version != "" {
+			w.Header().Set(HeaderAPIVersion, c.Version)
+		}
+		h.handleError(w, r, err)
+	})
+}
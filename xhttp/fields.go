@@ -0,0 +1,545 @@
+package xhttp
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Just-maple/xmux"
+)
+
+// BindQuery binds query values into the fields of the struct ptr points to.
+// Only fields with a `query` or `in:"query"` tag are bound, by the tag
+// name, falling back to the `json` tag name.
+//
+// Bool fields accept 1/0, true/false, yes/no and on/off in any case, and
+// a bare flag such as ?active is true.
+//
+// Slice fields collect every value of a repeated key (?role=a&role=b).
+// With the delimited option, `query:"role,delimited"`, each value is
+// also split on commas, so ?role=a,b&role=c yields [a b c]. Without it,
+// "a,b" is kept as a single element.
+//
+// A map[string]string or url.Values field tagged `query:"*"` collects
+// every query value not bound to another field, for generic filters.
+// A map[string]string keeps the first value of a repeated key, while
+// url.Values keeps all of them.
+func BindQuery(ptr any, values url.Values) error {
+	if err := bindValues(ptr, "query", func(name string) ([]string, bool) {
+		v, ok := values[name]
+		return v, ok && len(v) > 0
+	}); err != nil {
+		return err
+	}
+	v := reflect.ValueOf(ptr)
+	if v.Kind() == reflect.Pointer && !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+		structFieldsOf(v.Elem().Type()).setQueryRest(v.Elem(), values)
+	}
+	return nil
+}
+
+// BindPath binds path parameters into the fields of the struct ptr points to.
+// Only fields with a `path` or `in:"path"` tag are bound, by the tag
+// name, falling back to the `json` tag name.
+func BindPath(ptr any, params map[string]string) error {
+	if len(params) == 0 {
+		return nil
+	}
+	return bindValues(ptr, "path", func(name string) ([]string, bool) {
+		v, ok := params[name]
+		return []string{v}, ok
+	})
+}
+
+// bindValues sets every field of the struct ptr points to whose name
+// (resolved from tag) is found by lookup.
+func bindValues(ptr any, tag string, lookup func(name string) ([]string, bool)) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	return setFields(v, tag, structFieldsOf(v.Type()).bySource[tag], lookup)
+}
+
+// setFields sets the given fields of struct v from lookup, which returns
+// all values of a name. Scalar fields take the first value; slice fields
+// take every value, each split on commas if the field is delimited.
+func setFields(v reflect.Value, tag string, fields []boundField, lookup func(name string) ([]string, bool)) error {
+	for _, field := range fields {
+		raw, ok := lookup(field.name)
+		if !ok {
+			continue
+		}
+		var err error
+		if field.slice {
+			err = setSlice(fieldByIndex(v, field.index), raw, field.delimited, field.convert)
+		} else {
+			err = field.convert(fieldByIndex(v, field.index), raw[0])
+		}
+		if err != nil {
+			return &xmux.BindError{Type: tag, Field: field.field, Err: err}
+		}
+	}
+	return nil
+}
+
+// setSlice sets slice v from raw, converting each element with convert.
+func setSlice(v reflect.Value, raw []string, delimited bool, convert converter) error {
+	if delimited {
+		var split []string
+		for _, r := range raw {
+			split = append(split, strings.Split(r, ",")...)
+		}
+		raw = split
+	}
+	slice := reflect.MakeSlice(v.Type(), len(raw), len(raw))
+	for i, r := range raw {
+		if err := convert(slice.Index(i), r); err != nil {
+			return err
+		}
+	}
+	v.Set(slice)
+	return nil
+}
+
+// bindSources lists the tags of the request parts bound by name.
+var bindSources = []string{"query", "path", "header"}
+
+// converter parses raw and stores the result in v.
+type converter func(v reflect.Value, raw string) error
+
+// boundField is the precomputed binding metadata of a struct field.
+type boundField struct {
+	// index is the field index path, see fieldByIndex
+	index []int
+
+	// name is the name of the field in the request source
+	name string
+
+	// field is the dotted Go field path from the params struct through
+	// embedded structs, e.g. "CreateUserRequest.Email", used in errors
+	field string
+
+	// convert parses request values into the field, or into the
+	// elements of a slice field
+	convert converter
+
+	// slice is set for slice fields, which take every value of the name
+	slice bool
+
+	// delimited is set by the ",delimited" tag option: values of a slice
+	// field are also split on commas
+	delimited bool
+}
+
+// structFields holds the bound fields of a struct type per source tag.
+type structFields struct {
+	bySource map[string][]boundField
+
+	// body is the index of the field tagged `in:"body"`, nil if the
+	// body is decoded into the whole struct
+	body []int
+
+	// validated holds the fields with `validate` rules
+	validated []validatedField
+
+	// queryRest is the index of the map field tagged `query:"*"`, nil if
+	// unmatched query values are ignored
+	queryRest []int
+}
+
+// setQueryRest stores the query values matching no query field of f into
+// the `query:"*"` field of struct v, if any.
+func (f *structFields) setQueryRest(v reflect.Value, values url.Values) {
+	if f.queryRest == nil {
+		return
+	}
+	var rest reflect.Value
+	for name, value := range values {
+		if len(value) == 0 || f.boundQuery(name) {
+			continue
+		}
+		if !rest.IsValid() {
+			rest = fieldByIndex(v, f.queryRest)
+			if rest.IsNil() {
+				rest.Set(reflect.MakeMap(rest.Type()))
+			}
+		}
+		if rest.Type().Elem().Kind() == reflect.String {
+			rest.SetMapIndex(reflect.ValueOf(name), reflect.ValueOf(value[0]).Convert(rest.Type().Elem()))
+		} else {
+			rest.SetMapIndex(reflect.ValueOf(name), reflect.ValueOf(value))
+		}
+	}
+}
+
+// boundQuery reports whether a query field of f is bound from name.
+func (f *structFields) boundQuery(name string) bool {
+	for _, field := range f.bySource["query"] {
+		if field.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldCache caches structFields by reflect.Type so struct tags are
+// parsed once per type rather than on every request.
+var fieldCache sync.Map
+
+// structFieldsOf returns the cached binding metadata for struct type t.
+func structFieldsOf(t reflect.Type) *structFields {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.(*structFields)
+	}
+	fields := &structFields{bySource: make(map[string][]boundField, len(bindSources))}
+	fields.collect(t, nil, "", "", map[reflect.Type]bool{t: true})
+	cached, _ := fieldCache.LoadOrStore(t, fields)
+	return cached.(*structFields)
+}
+
+// collect adds the bound fields of struct type t, whose index within the
+// root struct starts with prefix, and whose Go field path is path.
+// Untagged embedded structs, and pointers to them, are walked recursively
+// as encoding/json does; seen guards against recursive embedding. in is
+// the `in` tag inherited from an embedding field, applying to fields
+// without their own.
+func (f *structFields) collect(t reflect.Type, prefix []int, path string, in string, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		index := append(append(make([]int, 0, len(prefix)+1), prefix...), i)
+		fieldIn := field.Tag.Get("in")
+		if fieldIn == "" {
+			fieldIn = in
+		}
+		if embedded := embeddedStruct(field); embedded != nil {
+			if !seen[embedded] {
+				seen[embedded] = true
+				f.collect(embedded, index, path+field.Name+".", fieldIn, seen)
+				delete(seen, embedded)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if queryRest(field) && f.queryRest == nil {
+			f.queryRest = index
+			continue
+		}
+		if field.Tag.Get("in") == "body" && f.body == nil {
+			// The field receives the whole request body
+			f.body = index
+			continue
+		}
+		if validated, ok := validationRules(field, index); ok {
+			f.validated = append(f.validated, validated)
+		}
+		slice := field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() != reflect.Uint8
+		convert := converterFor(field.Type)
+		if slice {
+			convert = converterFor(field.Type.Elem())
+		}
+		for _, tag := range bindSources {
+			if name := fieldName(field, tag, fieldIn); name != "" {
+				_, opts, _ := strings.Cut(field.Tag.Get(tag), ",")
+				f.bySource[tag] = append(f.bySource[tag], boundField{
+					index:     index,
+					name:      name,
+					field:     path + field.Name,
+					convert:   convert,
+					slice:     slice,
+					delimited: opts == "delimited",
+				})
+			}
+		}
+	}
+}
+
+// queryRest reports whether field is a query catch-all: a
+// map[string]string or url.Values field tagged `query:"*"`.
+func queryRest(field reflect.StructField) bool {
+	if field.Tag.Get("query") != "*" || field.Type.Kind() != reflect.Map || field.Type.Key().Kind() != reflect.String {
+		return false
+	}
+	elem := field.Type.Elem()
+	return elem.Kind() == reflect.String || elem == reflect.TypeOf([]string(nil))
+}
+
+// lookupField returns the nested field of v for index without allocating.
+// It reports false if a pointer on the way, or the field itself, is nil.
+func lookupField(v reflect.Value, index []int) (reflect.Value, bool) {
+	for _, x := range index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	return v, true
+}
+
+// embeddedStruct returns the struct type of an embedded field whose
+// fields are promoted for binding, or nil if field is not one.
+func embeddedStruct(field reflect.StructField) reflect.Type {
+	if !field.Anonymous {
+		return nil
+	}
+	for _, tag := range append([]string{"json"}, bindSources...) {
+		if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" {
+			return nil
+		}
+	}
+	t := field.Type
+	if t.Kind() == reflect.Pointer {
+		// A nil pointer to an unexported type cannot be allocated
+		if !field.IsExported() {
+			return nil
+		}
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+// fieldByIndex returns the nested field of v for index, allocating nil
+// embedded struct pointers on the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// PrecompileBinding parses the binding metadata of the params type ahead
+// of the first request. ptr may be a struct value or a pointer to one,
+// such as the result of xmux.Api.Params.
+func PrecompileBinding(ptr any) {
+	CompileBind(reflect.TypeOf(ptr))
+}
+
+// fieldName resolves the name of field for the given source tag.
+// Fields must be opted in to a source with a tag of that source or an
+// `in` tag naming it, so a field with only a `json` name is bound from
+// the body alone and cannot be set through the URL. A tag without a
+// name, such as `query:",delimited"`, falls back to the `json` name.
+// Returns "" if the field is not bound from the source, including for a
+// "-" name or an `in` tag naming another source.
+func fieldName(field reflect.StructField, tag string, in string) string {
+	if in != "" && in != tag {
+		return ""
+	}
+	value, ok := field.Tag.Lookup(tag)
+	if !ok && in == "" {
+		return ""
+	}
+	if name, _, _ := strings.Cut(value, ","); name != "" {
+		if name == "-" {
+			return ""
+		}
+		return name
+	}
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" {
+		if name == "-" {
+			return ""
+		}
+		return name
+	}
+	return field.Name
+}
+
+// converters holds the converters added by RegisterConverter by type.
+var converters sync.Map
+
+// textUnmarshalerType is the reflect.Type of encoding.TextUnmarshaler.
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// RegisterConverter registers fn to parse query and path values into
+// fields of type T, taking precedence over encoding.TextUnmarshaler and
+// the built-in conversions. Pointers to T are handled as well.
+//
+// Converters must be registered before the first route using T is
+// created, as binding metadata is compiled once per params type.
+//
+// Example:
+//
+//	xhttp.RegisterConverter(func(raw string) (uuid.UUID, error) {
+//	    return uuid.Parse(raw)
+//	})
+func RegisterConverter[T any](fn func(raw string) (T, error)) {
+	converters.Store(reflect.TypeOf((*T)(nil)).Elem(), converter(func(v reflect.Value, raw string) error {
+		value, err := fn(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(&value).Elem())
+		return nil
+	}))
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// timeLayouts are the layouts tried, in order, when parsing time.Time.
+var (
+	timeLayoutsMu sync.RWMutex
+	timeLayouts   = []string{time.RFC3339}
+)
+
+// RegisterTimeLayouts adds layouts for parsing time.Time query and path
+// values. They are tried after the previously registered ones, RFC 3339
+// being the first. Values matching no layout are parsed as unix seconds.
+//
+// Example:
+//
+//	xhttp.RegisterTimeLayouts("2006-01-02")
+func RegisterTimeLayouts(layouts ...string) {
+	timeLayoutsMu.Lock()
+	timeLayouts = append(timeLayouts, layouts...)
+	timeLayoutsMu.Unlock()
+}
+
+// parseTime parses raw with the registered layouts, falling back to
+// unix seconds.
+func parseTime(raw string) (time.Time, error) {
+	timeLayoutsMu.RLock()
+	defer timeLayoutsMu.RUnlock()
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, nil
+		}
+	}
+	if sec, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", raw)
+}
+
+// parseBool parses a flag: 1, true, yes and on are true, 0, false, no
+// and off are false, in any case, as are t and f like strconv.ParseBool.
+// An empty value, as of a bare ?active, is true.
+func parseBool(raw string) (bool, error) {
+	switch strings.ToLower(raw) {
+	case "", "1", "t", "true", "yes", "on":
+		return true, nil
+	case "0", "f", "false", "no", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", raw)
+}
+
+// converterFor returns the converter for values of type t.
+func converterFor(t reflect.Type) converter {
+	if registered, ok := converters.Load(t); ok {
+		return registered.(converter)
+	}
+	switch t {
+	case timeType:
+		return func(v reflect.Value, raw string) error {
+			tm, err := parseTime(raw)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(tm))
+			return nil
+		}
+	case durationType:
+		return func(v reflect.Value, raw string) error {
+			d, err := time.ParseDuration(raw)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+	}
+	if t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return func(v reflect.Value, raw string) error {
+			return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+		}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem := converterFor(t.Elem())
+		return func(v reflect.Value, raw string) error {
+			ptr := reflect.New(t.Elem())
+			if err := elem(ptr.Elem(), raw); err != nil {
+				return err
+			}
+			v.Set(ptr)
+			return nil
+		}
+	case reflect.String:
+		return func(v reflect.Value, raw string) error {
+			v.SetString(raw)
+			return nil
+		}
+	case reflect.Bool:
+		return func(v reflect.Value, raw string) error {
+			b, err := parseBool(raw)
+			if err != nil {
+				return err
+			}
+			v.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value, raw string) error {
+			n, err := strconv.ParseInt(raw, 10, t.Bits())
+			if err != nil {
+				return err
+			}
+			v.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(v reflect.Value, raw string) error {
+			n, err := strconv.ParseUint(raw, 10, t.Bits())
+			if err != nil {
+				return err
+			}
+			v.SetUint(n)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(v reflect.Value, raw string) error {
+			f, err := strconv.ParseFloat(raw, t.Bits())
+			if err != nil {
+				return err
+			}
+			v.SetFloat(f)
+			return nil
+		}
+	default:
+		return func(v reflect.Value, raw string) error {
+			return fmt.Errorf("unsupported type %s", t)
+		}
+	}
+}
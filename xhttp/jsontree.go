@@ -0,0 +1,232 @@
+package xhttp
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// EmptyFields selects how JSON responses treat empty values.
+type EmptyFields int
+
+const (
+	// EmptyAsTagged leaves empty values to the json struct tags
+	EmptyAsTagged EmptyFields = iota
+
+	// EmptyOmit drops every object member whose value is null, false,
+	// 0, "", [] or {}, as if every field was tagged omitempty
+	EmptyOmit
+
+	// EmptyInclude writes every exported struct field, ignoring
+	// omitempty tags
+	EmptyInclude
+)
+
+// member is a key and value of an object.
+type member struct {
+	key   string
+	value any
+}
+
+// object is a JSON object keeping the order of its members.
+type object []member
+
+// MarshalJSON encodes the members in order without HTML escaping; the
+// encoder of the response applies its own escaping and indentation.
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := enc.Encode(m.key); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+		buf.WriteByte(':')
+		if err := enc.Encode(m.value); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// readTree decodes the next JSON value of dec into objects, []any and
+// scalars, with numbers kept as json.Number.
+func readTree(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := object{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := readTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key: key.(string), value: value})
+		}
+		_, err = dec.Token()
+		return obj, err
+	case json.Delim('['):
+		list := []any{}
+		for dec.More() {
+			value, err := readTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err = dec.Token()
+		return list, err
+	}
+	return tok, nil
+}
+
+// rewriteTree renames the keys of every object in v with transform, if
+// not nil, and drops empty members if omitEmpty is set.
+func rewriteTree(v any, transform func(string) string, omitEmpty bool) any {
+	switch v := v.(type) {
+	case object:
+		out := make(object, 0, len(v))
+		for _, m := range v {
+			value := rewriteTree(m.value, transform, omitEmpty)
+			if omitEmpty && isEmptyValue(value) {
+				continue
+			}
+			if transform != nil {
+				m.key = transform(m.key)
+			}
+			out = append(out, member{key: m.key, value: value})
+		}
+		return out
+	case []any:
+		for i := range v {
+			v[i] = rewriteTree(v[i], transform, omitEmpty)
+		}
+	}
+	return v
+}
+
+// isEmptyValue reports whether a decoded value is null, false, 0, "",
+// [] or {}.
+func isEmptyValue(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case json.Number:
+		f, err := v.Float64()
+		return err == nil && f == 0
+	case object:
+		return len(v) == 0
+	case []any:
+		return len(v) == 0
+	}
+	return false
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// includeEmpty returns v with every struct replaced by an object holding
+// all its exported fields, so omitempty tags have no effect. Values with
+// custom JSON or text encoding are kept as is, through an addressable
+// copy if only their pointer type has the methods.
+func includeEmpty(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type().Implements(marshalerType) || v.Type().Implements(textMarshalerType) {
+		return v.Interface()
+	}
+	if pt := reflect.PointerTo(v.Type()); pt.Implements(marshalerType) || pt.Implements(textMarshalerType) {
+		if !v.CanAddr() {
+			addressable := reflect.New(v.Type()).Elem()
+			addressable.Set(v)
+			v = addressable
+		}
+		return v.Addr().Interface()
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return includeEmpty(v.Elem())
+	case reflect.Struct:
+		obj := object{}
+		includeStruct(v, &obj)
+		return obj
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return v.Interface()
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = includeEmpty(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v.Interface()
+		}
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, ok := mapKey(iter.Key())
+			if !ok {
+				// encoding/json reports the unsupported key type
+				return v.Interface()
+			}
+			out[key] = includeEmpty(iter.Value())
+		}
+		return out
+	}
+	return v.Interface()
+}
+
+// includeStruct appends the fields of struct v to obj, flattening
+// untagged embedded structs as encoding/json does.
+func includeStruct(v reflect.Value, obj *object) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if field.Anonymous && name == "" {
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				includeStruct(fv, obj)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		*obj = append(*obj, member{key: name, value: includeEmpty(fv)})
+	}
+}
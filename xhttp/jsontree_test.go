@@ -0,0 +1,27 @@
+package xhttp
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+type includedItem struct {
+	Custom pointerMarshaler            `json:"custom"`
+	Note   string                      `json:"note,omitempty"`
+	ByKey  map[string]pointerMarshaler `json:"by_key,omitempty"`
+}
+
+func TestIncludeEmpty(t *testing.T) {
+	v := map[string]includedItem{
+		`a"b<c>`: {Custom: pointerMarshaler{Name: "x"}, ByKey: map[string]pointerMarshaler{"k": {Name: "y"}}},
+	}
+	rec := httptest.NewRecorder()
+	if err := (JSONConfig{Empty: EmptyInclude}).write(context.Background(), rec, 200, v); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"a\"b\u003cc\u003e":{"custom":"custom x","note":"","by_key":{"k":"custom y"}}}` + "\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+package xhttp
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/Just-maple/xmux"
+)
+
+// keyTransforms maps the values of xmux.OptionKeyCase to transforms;
+// xmux.KeyCaseTags maps to nil, keeping the tag names.
+var keyTransforms = map[string]func(string) string{
+	xmux.KeyCaseTags:  nil,
+	xmux.KeyCaseCamel: CamelCase,
+	xmux.KeyCaseSnake: SnakeCase,
+}
+
+// SnakeCase converts a key to snake_case: "FullName", "fullName" and
+// "full_name" all become "full_name", and acronyms stay one word, so
+// "UserID" becomes "user_id".
+func SnakeCase(key string) string {
+	runes := []rune(key)
+	var b strings.Builder
+	for i, r := range runes {
+		if r == '-' || r == ' ' {
+			r = '_'
+		}
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			acronymEnd := unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || acronymEnd {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// CamelCase converts a key to camelCase: "FullName", "fullName" and
+// "full_name" all become "fullName", and "UserID" becomes "userId".
+func CamelCase(key string) string {
+	words := strings.Split(SnakeCase(key), "_")
+	var b strings.Builder
+	for i, word := range words {
+		if word == "" {
+			continue
+		}
+		if i > 0 && b.Len() > 0 {
+			runes := []rune(word)
+			runes[0] = unicode.ToUpper(runes[0])
+			word = string(runes)
+		}
+		b.WriteString(word)
+	}
+	return b.String()
+}
@@ -0,0 +1,513 @@
+package xhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Just-maple/xmux"
+)
+
+// handleResponse writes the handler result as JSON with status 200, or
+// the status of a result implementing interface{ StatusCode() int }.
+// A result implementing interface{ Location() string }, such as
+// xmux.Created, sets the Location header, and one implementing
+// interface{ Headers() map[string]string } sets the returned headers.
+// A result implementing xmux.Paginated sets the X-Total-Count header and
+// a Link header to the next and previous pages, see pageHeaders.
+// A result implementing interface{ LastModified() time.Time } sets the
+// Last-Modified header, and answers 304 to a GET with an
+// If-Modified-Since not older than it.
+// An *xmux.SSEResponse is streamed as Server-Sent Events instead.
+// Results with status 204 or 304 are written without a body.
+// A []byte result is written verbatim, with the first type of the route's
+// produces option as Content-Type or else a sniffed one, and a string
+// result is written as text/plain; neither is enveloped.
+// With enveloping enabled other results are wrapped in an xmux.Envelope.
+// If fields is not nil, only those fields of the result are written.
+// Struct fields tagged visible, at any depth of the result, are written
+// only if the role of caller is listed, e.g. `visible:"admin"`, or if the
+// list has "self" and the struct is owned by the subject of caller, see
+// xmux.WithRole and xmux.WithSubject.
+// Encoding stops without writing a body once the client disconnects.
+func (h *Handler) handleResponse(w http.ResponseWriter, r *http.Request, result any, fields map[string]bool, caller xmux.Caller) {
+	if sse, ok := result.(*xmux.SSEResponse); ok && sse != nil {
+		streamEvents(w, r, sse)
+		return
+	}
+	status := http.StatusOK
+	if coder, ok := result.(interface{ StatusCode() int }); ok && !isNil(result) && coder.StatusCode() > 0 {
+		status = coder.StatusCode()
+	}
+	if locator, ok := result.(interface{ Location() string }); ok && !isNil(result) {
+		if location := locator.Location(); location != "" {
+			w.Header().Set("Location", location)
+		}
+	}
+	if headers, ok := result.(interface{ Headers() map[string]string }); ok && !isNil(result) {
+		for k, v := range headers.Headers() {
+			w.Header().Set(k, v)
+		}
+	}
+	if page, ok := result.(xmux.Paginated); ok && !isNil(result) {
+		pageHeaders(w.Header(), r.URL, page.Page())
+	}
+	if modifier, ok := result.(interface{ LastModified() time.Time }); ok && !isNil(result) {
+		if lastModified(w, r, modifier.LastModified()) && status == http.StatusOK {
+			status = http.StatusNotModified
+		}
+	}
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		w.WriteHeader(status)
+		return
+	}
+	switch raw := result.(type) {
+	case []byte:
+		contentType := http.DetectContentType(raw)
+		if len(h.produces) > 0 {
+			contentType = h.produces[0]
+		}
+		writeRaw(w, status, contentType, raw)
+		return
+	case string:
+		writeRaw(w, status, "text/plain; charset=utf-8", []byte(raw))
+		return
+	}
+	config := h.config.JSON
+	config.filter, config.filterData = h.responseFilter(result, fields, caller), h.envelope
+	if h.envelope {
+		result = envelope(result)
+	}
+	if err := config.write(r.Context(), w, status, result); err != nil && h.config.Debug {
+		log.Printf("xhttp: %s %s: response encoding aborted: %v", h.method, h.pattern, err)
+	}
+}
+
+// writeRaw writes body verbatim with the given content type.
+func writeRaw(w http.ResponseWriter, status int, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// envelope wraps result in an xmux.Envelope, taking the meta from a
+// result implementing xmux.Paginated.
+func envelope(result any) xmux.Envelope {
+	env := xmux.Envelope{Data: result}
+	if page, ok := result.(xmux.Paginated); ok && !isNil(result) {
+		meta := page.Page()
+		env.Meta = &meta
+	}
+	return env
+}
+
+// HeaderTotalCount is the response header carrying the total number of
+// items of a paginated list.
+const HeaderTotalCount = "X-Total-Count"
+
+// pageHeaders sets the X-Total-Count header to the total of page and a
+// Link header with the rel="next" and rel="prev" pages, if any, reusing
+// the request URL with the limit and offset query parameters replaced.
+func pageHeaders(header http.Header, u *url.URL, page xmux.PageMeta) {
+	header.Set(HeaderTotalCount, strconv.Itoa(page.Total))
+	if page.Limit <= 0 {
+		return
+	}
+	link := func(offset int, rel string) string {
+		query := u.Query()
+		query.Set("limit", strconv.Itoa(page.Limit))
+		query.Set("offset", strconv.Itoa(offset))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, u.Path, query.Encode(), rel)
+	}
+	var links []string
+	if page.Offset+page.Limit < page.Total {
+		links = append(links, link(page.Offset+page.Limit, "next"))
+	}
+	if page.Offset > 0 {
+		prev := page.Offset - page.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		links = append(links, link(prev, "prev"))
+	}
+	if len(links) > 0 {
+		header.Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// isNil reports whether v is a nil pointer, map, slice or interface.
+func isNil(v any) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return !rv.IsValid()
+}
+
+// handleError writes err as the response. A non-nil error always takes
+// precedence over the result returned with it, which is discarded.
+//
+// The status code is taken from the error (see xmux.StatusCode),
+// defaulting to 400 Bad Request, or Config.ValidationStatus for an
+// xmux.ValidationError. An xmux.PartialError in the chain has
+// its Body written as is; any other error is written as a JSON error
+// envelope, listing the fields of an xmux.ValidationError under "errors"
+// and the field of an xmux.BindError under "field".
+// Headers of an xmux.HTTPError are copied to the response.
+func (h *Handler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	var partial *xmux.PartialError
+	if errors.As(err, &partial) {
+		_ = h.config.JSON.write(r.Context(), w, xmux.StatusCode(err, http.StatusBadRequest), partial.Body)
+		return
+	}
+	var httpErr *xmux.HTTPError
+	if errors.As(err, &httpErr) {
+		for k, v := range httpErr.Header {
+			w.Header()[k] = v
+		}
+	}
+	status := xmux.StatusCode(err, http.StatusBadRequest)
+	var validationErr *xmux.ValidationError
+	var fields map[string]string
+	if errors.As(err, &validationErr) {
+		fields = validationErr.Fields
+		if h.config.ValidationStatus > 0 {
+			status = h.config.ValidationStatus
+		}
+	}
+	var bindErr *xmux.BindError
+	var field string
+	if errors.As(err, &bindErr) {
+		field = bindErr.Field
+	}
+	id, _ := xmux.RequestIDFromContext(r.Context())
+	_ = h.config.JSON.write(r.Context(), w, status, errorResponse{
+		Error:     err.Error(),
+		Code:      xmux.ErrorCode(err),
+		Field:     field,
+		Errors:    fields,
+		RequestID: id,
+	})
+}
+
+// errorResponse is the JSON error envelope.
+type errorResponse struct {
+	Error string `json:"error"`
+
+	// Code is the machine readable error code, if err carries one
+	Code string `json:"code,omitempty"`
+
+	// Field is the path of the field an xmux.BindError failed on, e.g.
+	// "CreateUserRequest.Email" or the JSON path "user.email" in a body
+	Field string `json:"field,omitempty"`
+
+	// Errors maps each field failing validation to its message
+	Errors map[string]string `json:"errors,omitempty"`
+
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// JSONConfig configures the JSON encoding of responses.
+// The zero value produces compact output with HTML escaping, as
+// encoding/json does by default.
+type JSONConfig struct {
+	// Indent indents output by this string per level; empty is compact
+	Indent string
+
+	// DisableHTMLEscape keeps <, > and & as is instead of escaping them,
+	// e.g. so URLs in responses stay readable
+	DisableHTMLEscape bool
+
+	// KeyTransform rewrites every object key of responses, map keys
+	// included, e.g. CamelCase or SnakeCase; nil keeps the json tag names. Routes
+	// select a transform with xmux.WithKeyCase. Transforming decodes and
+	// re-encodes each response, roughly doubling the encoding cost, so
+	// prefer json tags on hot paths.
+	KeyTransform func(string) string
+
+	// Empty selects whether empty values follow the json tags, are
+	// omitted everywhere or are always included. Like KeyTransform,
+	// anything but EmptyAsTagged re-encodes each response.
+	Empty EmptyFields
+
+	// filter removes fields from the decoded response, see
+	// xmux.SparseFields and the visible tag; filterData applies it to the
+	// Data of an envelope
+	filter     func(tree any) any
+	filterData bool
+}
+
+// encodeBuffer is a buffer with a json.Encoder writing to it.
+type encodeBuffer struct {
+	bytes.Buffer
+	enc *json.Encoder
+}
+
+// bufferPool recycles response encoding buffers and their encoders.
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := new(encodeBuffer)
+		b.enc = json.NewEncoder(&b.Buffer)
+		return b
+	},
+}
+
+// getBuffer returns a pooled buffer whose encoder has the settings of c.
+// The encoder writes compact JSON; write indents the final document.
+func (c JSONConfig) getBuffer() *encodeBuffer {
+	b := bufferPool.Get().(*encodeBuffer)
+	b.enc.SetEscapeHTML(!c.DisableHTMLEscape)
+	return b
+}
+
+// putBuffer returns b to the pool.
+func putBuffer(b *encodeBuffer) {
+	b.Reset()
+	bufferPool.Put(b)
+}
+
+// write encodes v as the JSON response body with status.
+// The body is encoded to a pooled buffer first, so an encoding failure
+// results in 500 Internal Server Error rather than a truncated body.
+// Lists are encoded element by element, see streamEncoder; if ctx is
+// canceled while encoding, nothing is written and the context error is
+// returned.
+func (c JSONConfig) write(ctx context.Context, w http.ResponseWriter, status int, v any) error {
+	buf := c.getBuffer()
+	defer putBuffer(buf)
+
+	if c.Empty == EmptyInclude {
+		v = includeEmpty(reflect.ValueOf(v))
+	}
+	stream := &streamEncoder{ctx: ctx, buf: &buf.Buffer, enc: buf.enc}
+	err := stream.encode(reflect.ValueOf(v))
+	buf.WriteByte('\n')
+	if err == nil && (c.KeyTransform != nil || c.Empty == EmptyOmit || c.filter != nil) {
+		err = c.rewrite(buf)
+	}
+	if err == nil && c.Indent != "" {
+		err = indent(&buf.Buffer, c.Indent)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+	return nil
+}
+
+// indent indents the compact document in buf by prefix per level.
+func indent(buf *bytes.Buffer, prefix string) error {
+	indented := bufferPool.Get().(*encodeBuffer)
+	defer putBuffer(indented)
+	if err := json.Indent(&indented.Buffer, buf.Bytes(), "", prefix); err != nil {
+		return err
+	}
+	buf.Reset()
+	_, err := indented.WriteTo(buf)
+	return err
+}
+
+// rewrite applies the field selection, KeyTransform and EmptyOmit to the
+// compact document in buf, keeping the order of keys, and encodes the
+// result with the encoder of buf.
+func (c JSONConfig) rewrite(buf *encodeBuffer) error {
+	dec := json.NewDecoder(bytes.NewReader(buf.Bytes()))
+	dec.UseNumber()
+	tree, err := readTree(dec)
+	if err != nil {
+		return err
+	}
+	if c.filter != nil {
+		if env, ok := tree.(object); ok && c.filterData {
+			for i := range env {
+				if env[i].key == "data" {
+					env[i].value = c.filter(env[i].value)
+				}
+			}
+		} else {
+			tree = c.filter(tree)
+		}
+	}
+	tree = rewriteTree(tree, c.KeyTransform, c.Empty == EmptyOmit)
+	buf.Reset()
+	return buf.enc.Encode(tree)
+}
+
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// streamEncoder writes a response to buf through a single json.Encoder.
+// Lists are written element by element, checking ctx between elements,
+// which bounds the work spent on large lists for a client that is gone.
+type streamEncoder struct {
+	ctx context.Context
+	buf *bytes.Buffer
+	enc *json.Encoder
+}
+
+// encode writes v compactly without a trailing newline. Lists, and the
+// fields of an xmux.Envelope or of a struct implementing xmux.Paginated,
+// are streamed; other values are encoded as a whole.
+func (s *streamEncoder) encode(v reflect.Value) error {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch {
+	case isList(v):
+		return s.encodeList(v)
+	case isWrapper(v):
+		return s.encodeWrapper(v)
+	}
+	if v.CanAddr() && v.Kind() != reflect.Pointer && v.Kind() != reflect.Interface {
+		v = v.Addr()
+	}
+	if !v.IsValid() {
+		return s.encodeValue(nil)
+	}
+	return s.encodeValue(v.Interface())
+}
+
+// encodeValue writes v with the encoder, dropping its trailing newline.
+func (s *streamEncoder) encodeValue(v any) error {
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	s.buf.Truncate(s.buf.Len() - 1)
+	return nil
+}
+
+// encodeList writes the list v element by element.
+func (s *streamEncoder) encodeList(v reflect.Value) error {
+	s.buf.WriteByte('[')
+	for i := 0; i < v.Len(); i++ {
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+		if i > 0 {
+			s.buf.WriteByte(',')
+		}
+		if err := s.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	s.buf.WriteByte(']')
+	return nil
+}
+
+// encodeWrapper writes the fields of the struct v, or of the struct v
+// points to, as encoding/json would.
+func (s *streamEncoder) encodeWrapper(v reflect.Value) error {
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	s.buf.WriteByte('{')
+	first := true
+	var err error
+	walkFields(v.Type(), func(name string, field reflect.StructField) {
+		fv, ferr := v.FieldByIndexErr(field.Index)
+		if err != nil || ferr != nil {
+			return
+		}
+		_, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if hasOption(opts, "omitempty") && emptyField(fv) {
+			return
+		}
+		if !first {
+			s.buf.WriteByte(',')
+		}
+		first = false
+		if err = s.encodeValue(name); err == nil {
+			s.buf.WriteByte(':')
+			err = s.encode(fv)
+		}
+	})
+	s.buf.WriteByte('}')
+	return err
+}
+
+// isList reports whether v is a non-empty slice or array, other than
+// bytes, without custom JSON encoding.
+func isList(v reflect.Value) bool {
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return false
+	}
+	return v.Len() > 0 && v.Type().Elem().Kind() != reflect.Uint8 && !customJSON(v)
+}
+
+// envelopeType is the type of xmux.Envelope.
+var envelopeType = reflect.TypeOf(xmux.Envelope{})
+
+// isWrapper reports whether v is, or is a non-nil pointer to, an
+// xmux.Envelope or a struct implementing xmux.Paginated, without custom
+// JSON encoding and fields encoded as strings.
+func isWrapper(v reflect.Value) bool {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() || customJSON(v) {
+			return false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || customJSON(v) {
+		return false
+	}
+	t := v.Type()
+	if t != envelopeType && !t.Implements(paginatedType) && !reflect.PointerTo(t).Implements(paginatedType) {
+		return false
+	}
+	plain := true
+	walkFields(t, func(name string, field reflect.StructField) {
+		_, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		plain = plain && !hasOption(opts, "string")
+		// Fields promoted through unexported embedded structs cannot be
+		// read through reflection
+		for i := 1; i < len(field.Index); i++ {
+			plain = plain && t.FieldByIndex(field.Index[:i]).IsExported()
+		}
+	})
+	return plain
+}
+
+// hasOption reports whether the comma separated json tag options opts
+// hold option.
+func hasOption(opts string, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// emptyField reports whether a field with the omitempty option is
+// omitted, as in encoding/json.
+func emptyField(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package xhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Just-maple/xmux"
+)
+
+type pointerMarshaler struct{ Name string }
+
+func (p *pointerMarshaler) MarshalJSON() ([]byte, error) {
+	return json.Marshal("custom " + p.Name)
+}
+
+type streamPage struct {
+	Items []pointerMarshaler `json:"items"`
+	Next  string             `json:"next,omitempty"`
+	Total int                `json:"total"`
+}
+
+func (p *streamPage) Page() xmux.PageMeta { return xmux.PageMeta{Total: p.Total} }
+
+func TestWriteMatchesEncodingJSON(t *testing.T) {
+	items := []pointerMarshaler{{Name: "a"}, {Name: "<b>"}}
+	cases := []struct {
+		name string
+		v    any
+	}{
+		{"slice", items},
+		{"pointer elements", []*pointerMarshaler{{Name: "a"}, nil}},
+		{"array", [2]pointerMarshaler{{Name: "a"}, {Name: "b"}}},
+		{"page", &streamPage{Items: items, Total: 2}},
+		{"enveloped page", xmux.Envelope{Data: &streamPage{Items: items, Total: 2}, Meta: &xmux.PageMeta{Total: 2}}},
+		{"enveloped list", xmux.Envelope{Data: items}},
+		{"nested lists", [][]int{{1, 2}, {}, nil}},
+		{"empty", []int{}},
+		{"nil", nil},
+		{"struct", pointerMarshaler{Name: "a"}},
+	}
+	for _, tc := range cases {
+		for _, config := range []JSONConfig{{}, {Indent: "  ", DisableHTMLEscape: true}} {
+			t.Run(tc.name, func(t *testing.T) {
+				want, err := json.Marshal(tc.v)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if config.Indent != "" {
+					var buf bytes.Buffer
+					enc := json.NewEncoder(&buf)
+					enc.SetEscapeHTML(false)
+					enc.SetIndent("", config.Indent)
+					if err := enc.Encode(tc.v); err != nil {
+						t.Fatal(err)
+					}
+					want = bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+				}
+				rec := httptest.NewRecorder()
+				if err := config.write(context.Background(), rec, 200, tc.v); err != nil {
+					t.Fatal(err)
+				}
+				if got := rec.Body.String(); got != string(want)+"\n" {
+					t.Errorf("got %s\nwant %s", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestWriteCanceledList(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	err := JSONConfig{}.write(ctx, rec, 200, &streamPage{Items: make([]pointerMarshaler, 3)})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("body written for a canceled request: %s", rec.Body)
+	}
+}
+
+func TestWriteSettingsWithRewrite(t *testing.T) {
+	v := map[string]any{"link": "<a>", "empty": "", "list": []int{1}}
+	for _, config := range []JSONConfig{
+		{Indent: "\t", Empty: EmptyOmit},
+		{Indent: "\t", Empty: EmptyOmit, DisableHTMLEscape: true},
+	} {
+		rec := httptest.NewRecorder()
+		if err := config.write(context.Background(), rec, 200, v); err != nil {
+			t.Fatal(err)
+		}
+		var want bytes.Buffer
+		enc := json.NewEncoder(&want)
+		enc.SetIndent("", "\t")
+		enc.SetEscapeHTML(!config.DisableHTMLEscape)
+		if err := enc.Encode(map[string]any{"link": "<a>", "list": []int{1}}); err != nil {
+			t.Fatal(err)
+		}
+		if got := rec.Body.String(); got != want.String() {
+			t.Errorf("DisableHTMLEscape %v: got %s\nwant %s", config.DisableHTMLEscape, got, want.String())
+		}
+	}
+}
+
+func BenchmarkWrite(b *testing.B) {
+	v := &streamPage{Items: make([]pointerMarshaler, 20), Total: 20}
+	config := JSONConfig{DisableHTMLEscape: true}
+	rec := httptest.NewRecorder()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rec.Body.Reset()
+		_ = config.write(context.Background(), rec, 200, v)
+	}
+}
@@ -0,0 +1,49 @@
+package xhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ShutdownTimeout bounds how long RunWithGracefulShutdown waits for
+// in-flight requests to drain.
+var ShutdownTimeout = 30 * time.Second
+
+// RunWithGracefulShutdown serves handler on addr until ctx is canceled or
+// the process receives SIGINT/SIGTERM, then shuts the server down,
+// letting in-flight requests drain for up to ShutdownTimeout.
+// Every adapter implementing http.Handler can be served this way.
+//
+// Example:
+//
+//	log.Fatal(xhttp.RunWithGracefulShutdown(context.Background(), ":8080", controller))
+func RunWithGracefulShutdown(ctx context.Context, addr string, handler http.Handler) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: addr, Handler: handler}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
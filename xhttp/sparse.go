@@ -0,0 +1,385 @@
+package xhttp
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Just-maple/xmux"
+)
+
+// responseStruct returns the struct type t is, points to or lists, or
+// nil if t is not such a type.
+func responseStruct(t reflect.Type) reflect.Type {
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+// paginatedType is the type of xmux.Paginated.
+var paginatedType = reflect.TypeOf((*xmux.Paginated)(nil)).Elem()
+
+// responseItems returns the struct type whose fields the fields query
+// parameter selects for the response type t, or nil if there is none.
+// That is t itself or the elements of a list t; for a page t, a struct
+// implementing xmux.Paginated with a single list of structs, it is the
+// elements of that list, and list is the JSON name of the list field.
+func responseItems(t reflect.Type) (item reflect.Type, list string) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t != nil && t.Kind() == reflect.Struct && (t.Implements(paginatedType) || reflect.PointerTo(t).Implements(paginatedType)) {
+		var lists []string
+		walkFields(t, func(name string, field reflect.StructField) {
+			ft := field.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array {
+				if elem := responseStruct(ft.Elem()); elem != nil {
+					item, lists = elem, append(lists, name)
+				}
+			}
+		})
+		if len(lists) == 1 {
+			return item, lists[0]
+		}
+	}
+	return responseStruct(t), ""
+}
+
+// responseFieldNames returns the JSON names of the fields of struct t,
+// or nil if t is nil.
+func responseFieldNames(t reflect.Type) map[string]bool {
+	if t == nil {
+		return nil
+	}
+	names := make(map[string]bool)
+	walkFields(t, func(name string, field reflect.StructField) {
+		names[name] = true
+	})
+	return names
+}
+
+// restrictedTypes caches restricted by type.
+var restrictedTypes sync.Map
+
+// restricted reports whether values of type t may hold a field tagged
+// visible, e.g. `visible:"admin"`, directly or in the structs, pointers,
+// slices, arrays and maps they hold. Fields of interface type are checked
+// on the value when the response is written.
+func restricted(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if cached, ok := restrictedTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	found := restrictedType(t, make(map[reflect.Type]bool))
+	restrictedTypes.Store(t, found)
+	return found
+}
+
+// restrictedType implements restricted, skipping the struct types of seen
+// to end recursive types.
+func restrictedType(t reflect.Type, seen map[reflect.Type]bool) bool {
+	for {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+			continue
+		case reflect.Interface:
+			return true
+		}
+		break
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return false
+	}
+	seen[t] = true
+	found := false
+	walkFields(t, func(name string, field reflect.StructField) {
+		found = found || field.Tag.Get("visible") != "" || restrictedType(field.Type, seen)
+	})
+	return found
+}
+
+// walkFields calls fn with the JSON name of every field of struct t,
+// flattening untagged embedded structs as encoding/json does. The Index
+// of a promoted field is its index path from t.
+func walkFields(t reflect.Type, fn func(name string, field reflect.StructField)) {
+	walkFieldsFrom(t, nil, fn)
+}
+
+// walkFieldsFrom implements walkFields for the struct t found at index.
+func walkFieldsFrom(t reflect.Type, index []int, fn func(name string, field reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		field.Index = append(append([]int(nil), index...), i)
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				walkFieldsFrom(ft, field.Index, fn)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fn(name, field)
+	}
+}
+
+// sparseFields returns the response fields selected by the fields query
+// parameter, or nil to return all fields. Unknown names are a BindError.
+func (h *Handler) sparseFields(r *http.Request) (map[string]bool, error) {
+	if h.options[xmux.OptionSparseFields] != "true" {
+		return nil, nil
+	}
+	selected := xmux.SplitOption(r.URL.Query().Get("fields"))
+	if len(selected) == 0 {
+		return nil, nil
+	}
+	fields := make(map[string]bool, len(selected))
+	for _, name := range selected {
+		if h.fieldNames != nil && !h.fieldNames[name] {
+			return nil, &xmux.BindError{Type: "query", Field: "fields", Err: fmt.Errorf("unknown field %q", name)}
+		}
+		fields[name] = true
+	}
+	return fields, nil
+}
+
+// responseFilter returns the rewrite of the encoded result removing the
+// fields hidden from caller and those not in the sparse fieldset, or nil
+// to write all fields. A result implementing interface{ Body() any },
+// such as xmux.Created, is encoded as the returned body.
+func (h *Handler) responseFilter(result any, fields map[string]bool, caller xmux.Caller) func(tree any) any {
+	if fields == nil && !h.restricted {
+		return nil
+	}
+	if wrapper, ok := result.(interface{ Body() any }); ok && !isNil(result) {
+		result = wrapper.Body()
+	}
+	return func(tree any) any {
+		if h.restricted {
+			tree = hideFields(tree, reflect.ValueOf(result), caller)
+		}
+		if fields != nil {
+			tree = h.selectFields(tree, fields)
+		}
+		return tree
+	}
+}
+
+// owner is implemented by values belonging to a subject, which sees
+// their fields tagged `visible:"self"`.
+type owner interface {
+	OwnerID() string
+}
+
+// hideFields removes from tree, the encoding of v, the struct fields
+// tagged visible whose roles do not include the role of caller, at any
+// depth. The role "self" matches when the struct is an owner of the
+// subject of caller. Values with custom JSON encoding are kept as is.
+func hideFields(tree any, v reflect.Value, caller xmux.Caller) any {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return tree
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() || customJSON(v) {
+		return tree
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		obj, ok := tree.(object)
+		if !ok {
+			return tree
+		}
+		fields := jsonFields(v.Type())
+		out := obj[:0]
+		for _, m := range obj {
+			field, ok := fields[m.key]
+			if !ok {
+				out = append(out, m)
+				continue
+			}
+			if roles := xmux.SplitOption(field.Tag.Get("visible")); len(roles) > 0 && !visibleTo(roles, v, caller) {
+				continue
+			}
+			if fv, err := v.FieldByIndexErr(field.Index); err == nil {
+				m.value = hideFields(m.value, fv, caller)
+			}
+			out = append(out, m)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		list, ok := tree.([]any)
+		if !ok || len(list) != v.Len() {
+			return tree
+		}
+		for i := range list {
+			list[i] = hideFields(list[i], v.Index(i), caller)
+		}
+	case reflect.Map:
+		obj, ok := tree.(object)
+		if !ok {
+			return tree
+		}
+		values := make(map[string]reflect.Value, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			if key, ok := mapKey(iter.Key()); ok {
+				values[key] = iter.Value()
+			}
+		}
+		for i := range obj {
+			if value, ok := values[obj[i].key]; ok {
+				obj[i].value = hideFields(obj[i].value, value, caller)
+			}
+		}
+	}
+	return tree
+}
+
+// visibleTo reports whether a field of struct v tagged visible with roles
+// is shown to caller.
+func visibleTo(roles []string, v reflect.Value, caller xmux.Caller) bool {
+	if caller.Role != "" && hasRole(roles, caller.Role) {
+		return true
+	}
+	if caller.Subject == "" || !hasRole(roles, "self") {
+		return false
+	}
+	if v.CanAddr() && v.Addr().CanInterface() {
+		if o, ok := v.Addr().Interface().(owner); ok {
+			return o.OwnerID() == caller.Subject
+		}
+	}
+	if v.CanInterface() {
+		if o, ok := v.Interface().(owner); ok {
+			return o.OwnerID() == caller.Subject
+		}
+	}
+	return false
+}
+
+// customJSON reports whether v is encoded by its own MarshalJSON or
+// MarshalText method, as encoding/json would call it.
+func customJSON(v reflect.Value) bool {
+	t := v.Type()
+	if t.Implements(marshalerType) || t.Implements(textMarshalerType) {
+		return true
+	}
+	pt := reflect.PointerTo(t)
+	return v.CanAddr() && (pt.Implements(marshalerType) || pt.Implements(textMarshalerType))
+}
+
+// mapKey returns the JSON object key of the map key k, as encoding/json
+// encodes it.
+func mapKey(k reflect.Value) (string, bool) {
+	if k.Kind() == reflect.String {
+		return k.String(), true
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return "", true
+		}
+		text, err := tm.MarshalText()
+		return string(text), err == nil
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), true
+	}
+	return "", false
+}
+
+// jsonFieldsCache caches jsonFields by type.
+var jsonFieldsCache sync.Map
+
+// jsonFields returns the fields of struct t by JSON name, with Index
+// holding the index path through embedded structs.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	if cached, ok := jsonFieldsCache.Load(t); ok {
+		return cached.(map[string]reflect.StructField)
+	}
+	fields := make(map[string]reflect.StructField)
+	walkFields(t, func(name string, field reflect.StructField) {
+		// The shallowest field of a name is encoded, as in encoding/json
+		if other, ok := fields[name]; !ok || len(field.Index) < len(other.Index) {
+			fields[name] = field
+		}
+	})
+	jsonFieldsCache.Store(t, fields)
+	return fields
+}
+
+// hasRole reports whether role is one of roles.
+func hasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// selectFields keeps only the selected fields of the encoded response, or
+// of the elements of its list field for a page.
+func (h *Handler) selectFields(tree any, fields map[string]bool) any {
+	keep := func(name string) bool { return fields[name] }
+	if h.listField == "" {
+		return filterFields(tree, keep)
+	}
+	if obj, ok := tree.(object); ok {
+		for i := range obj {
+			if obj[i].key == h.listField {
+				obj[i].value = filterFields(obj[i].value, keep)
+			}
+		}
+	}
+	return tree
+}
+
+// filterFields keeps only the members of v, or of each element if v is
+// a list, for which keep returns true.
+func filterFields(v any, keep func(name string) bool) any {
+	switch v := v.(type) {
+	case object:
+		out := make(object, 0, len(v))
+		for _, m := range v {
+			if keep(m.key) {
+				out = append(out, m)
+			}
+		}
+		return out
+	case []any:
+		for i := range v {
+			v[i] = filterFields(v[i], keep)
+		}
+	}
+	return v
+}
@@ -0,0 +1,189 @@
+package xhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Just-maple/xmux"
+)
+
+type visibleUser struct {
+	ID    string `json:"id"`
+	Email string `json:"email" visible:"admin,self"`
+	Notes string `json:"notes,omitempty" visible:"admin"`
+}
+
+func (u *visibleUser) OwnerID() string { return u.ID }
+
+type visibleTeam struct {
+	Lead    *visibleUser            `json:"lead"`
+	Members []*visibleUser          `json:"members"`
+	ByRole  map[string]visibleUser  `json:"by_role"`
+	Extra   any                     `json:"extra"`
+	Named   map[string]*visibleUser `json:"named"`
+}
+
+type emptyParams struct{}
+
+// serveAs serves a GET of api for a caller with role and subject, either
+// of which may be empty, and decodes the response body.
+func serveAs(t *testing.T, api xmux.Api, role string, subject string) map[string]any {
+	t.Helper()
+	api = xmux.Chain(api, func(next xmux.Invoker) xmux.Invoker {
+		return func(ctx context.Context, bind func(params any) error) (any, error) {
+			if role != "" {
+				ctx = xmux.WithRole(ctx, role)
+			}
+			if subject != "" {
+				ctx = xmux.WithSubject(ctx, subject)
+			}
+			return next(ctx, bind)
+		}
+	})
+	rec := httptest.NewRecorder()
+	NewHandler(http.MethodGet, "/team", api).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/team", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestVisibleFieldsNested(t *testing.T) {
+	api := xmux.NewHandler(func(ctx context.Context, params *emptyParams) (*visibleTeam, error) {
+		return &visibleTeam{
+			Lead:    &visibleUser{ID: "u1", Email: "u1@example.com", Notes: "lead"},
+			Members: []*visibleUser{{ID: "u1", Email: "u1@example.com"}, {ID: "u2", Email: "u2@example.com"}},
+			ByRole:  map[string]visibleUser{"owner": {ID: "u2", Email: "u2@example.com"}},
+			Extra:   visibleUser{ID: "u3", Email: "u3@example.com"},
+			Named:   map[string]*visibleUser{"bob": {ID: "u2", Email: "u2@example.com"}},
+		}, nil
+	})
+	email := func(body map[string]any, path ...string) (string, bool) {
+		var v any = body
+		for _, key := range path {
+			switch node := v.(type) {
+			case map[string]any:
+				v = node[key]
+			case []any:
+				v = node[key[0]-'0']
+			}
+		}
+		s, ok := v.(map[string]any)["email"].(string)
+		return s, ok
+	}
+	paths := [][]string{{"lead"}, {"members", "0"}, {"members", "1"}, {"by_role", "owner"}, {"extra"}, {"named", "bob"}}
+
+	cases := []struct {
+		name    string
+		role    string
+		subject string
+		visible map[string]bool
+	}{
+		{"viewer", "viewer", "", map[string]bool{}},
+		{"anonymous", "", "", map[string]bool{}},
+		{"admin", "admin", "", map[string]bool{"lead": true, "members0": true, "members1": true, "by_roleowner": true, "extra": true, "namedbob": true}},
+		// ByRole holds values, which are not addressable, so the pointer
+		// method OwnerID does not apply to them
+		{"self", "user", "u2", map[string]bool{"members1": true, "namedbob": true}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := serveAs(t, api, tc.role, tc.subject)
+			for _, path := range paths {
+				key := path[0]
+				if len(path) > 1 {
+					key += path[1]
+				}
+				if _, ok := email(body, path...); ok != tc.visible[key] {
+					t.Errorf("%v: email visible = %v, want %v", path, ok, tc.visible[key])
+				}
+			}
+			if _, ok := body["lead"].(map[string]any)["notes"]; ok != (tc.role == "admin") {
+				t.Errorf("lead notes visible = %v", ok)
+			}
+		})
+	}
+}
+
+func TestVisibleFieldsEnveloped(t *testing.T) {
+	api := xmux.NewHandler(func(ctx context.Context, params *emptyParams) ([]visibleUser, error) {
+		return []visibleUser{{ID: "u1", Email: "u1@example.com"}}, nil
+	})
+	for _, role := range []string{"viewer", "admin"} {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/users", nil).WithContext(xmux.WithRole(context.Background(), role))
+		Config{Envelope: true}.NewHandler(http.MethodGet, "/users", api).ServeHTTP(rec, r)
+		var body struct {
+			Data []map[string]any `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := body.Data[0]["email"]; ok != (role == "admin") {
+			t.Errorf("%s: email visible = %v: %s", role, ok, rec.Body)
+		}
+	}
+}
+
+func TestRestricted(t *testing.T) {
+	type node struct {
+		Children []*node `json:"children"`
+		Name     string  `json:"name"`
+	}
+	type plain struct {
+		Names map[string][]string `json:"names"`
+	}
+	cases := []struct {
+		t    any
+		want bool
+	}{
+		{visibleUser{}, true},
+		{[]*visibleUser{}, true},
+		{map[string]visibleTeam{}, true},
+		{node{}, false},
+		{plain{}, false},
+		{"", false},
+	}
+	for _, tc := range cases {
+		if got := restricted(reflect.TypeOf(tc.t)); got != tc.want {
+			t.Errorf("restricted(%T) = %v, want %v", tc.t, got, tc.want)
+		}
+	}
+}
+
+type userPage struct {
+	Users []*visibleUser `json:"users"`
+	Total int            `json:"total"`
+}
+
+func (p *userPage) Page() xmux.PageMeta { return xmux.PageMeta{Total: p.Total} }
+
+func TestSparseFieldsPage(t *testing.T) {
+	api := xmux.NewHandler(func(ctx context.Context, params *emptyParams) (*userPage, error) {
+		return &userPage{Users: []*visibleUser{{ID: "u1", Email: "u1@example.com"}}, Total: 1}, nil
+	})
+	h := NewHandler(http.MethodGet, "/users", api, xmux.SparseFields())
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?fields=id", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if got, want := rec.Body.String(), `{"users":[{"id":"u1"}],"total":1}`+"\n"; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?fields=total", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("fields of the page itself: status %d, want 400", rec.Code)
+	}
+}
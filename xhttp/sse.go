@@ -0,0 +1,81 @@
+package xhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Just-maple/xmux"
+)
+
+// streamEvents writes the events of sse to w until the channel is closed
+// or the request context is done.
+func streamEvents(w http.ResponseWriter, r *http.Request, sse *xmux.SSEResponse) {
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flush := func() {}
+	if flusher, ok := w.(http.Flusher); ok {
+		flush = flusher.Flush
+	}
+	flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sse.Events:
+			if !ok {
+				return
+			}
+			if err := WriteEvent(w, event); err != nil {
+				return
+			}
+			flush()
+		}
+	}
+}
+
+// WriteEvent writes event to w as a Server-Sent Events frame.
+// Multi-line data is split over several "data:" lines; non-string data
+// is encoded as JSON.
+func WriteEvent(w io.Writer, event xmux.Event) error {
+	var buf bytes.Buffer
+	if event.ID != "" {
+		writeField(&buf, "id", event.ID)
+	}
+	if event.Event != "" {
+		writeField(&buf, "event", event.Event)
+	}
+	if event.Retry > 0 {
+		writeField(&buf, "retry", strconv.FormatInt(event.Retry.Milliseconds(), 10))
+	}
+	data, ok := event.Data.(string)
+	if !ok && event.Data != nil {
+		b, err := json.Marshal(event.Data)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		writeField(&buf, "data", line)
+	}
+	buf.WriteByte('\n')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeField writes a single "name: value" line, dropping newlines in
+// value so it cannot inject extra fields.
+func writeField(buf *bytes.Buffer, name string, value string) {
+	buf.WriteString(name)
+	buf.WriteString(": ")
+	buf.WriteString(strings.NewReplacer("\r", "", "\n", "").Replace(value))
+	buf.WriteByte('\n')
+}
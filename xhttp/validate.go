@@ -0,0 +1,239 @@
+package xhttp
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Just-maple/xmux"
+)
+
+// validatedField is a field checked against the rules of its `validate`
+// tag after binding.
+type validatedField struct {
+	index []int
+
+	// name is the request name of the field, reported in errors
+	name string
+
+	// required fails a missing field; omitempty skips the rules of a
+	// missing field. A nil pointer is missing, as is a zero value unless
+	// pointer is set.
+	required  bool
+	omitempty bool
+	pointer   bool
+
+	rules []rule
+}
+
+// rule checks a bound value, returning the failure message or "".
+type rule func(v reflect.Value) string
+
+// validationRules parses the `validate` tag of field. Supported rules are
+// required, omitempty, email, min=N, max=N and oneof=a b c; rules of other
+// validators are ignored, as are rules not applying to the field type.
+// min and max bound the length of strings, slices and maps, and the value
+// of numbers. Rules other than required are not checked for nil pointers.
+func validationRules(field reflect.StructField, index []int) (validatedField, bool) {
+	tag := field.Tag.Get("validate")
+	if tag == "" {
+		return validatedField{}, false
+	}
+	t := field.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	f := validatedField{index: index, name: requestName(field), pointer: field.Type.Kind() == reflect.Pointer}
+	for _, r := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(r, "=")
+		switch name {
+		case "required":
+			f.required = true
+		case "omitempty":
+			f.omitempty = true
+		case "email":
+			if t.Kind() == reflect.String {
+				f.rules = append(f.rules, emailRule)
+			}
+		case "min", "max":
+			if r := boundRule(t, name == "min", arg); r != nil {
+				f.rules = append(f.rules, r)
+			}
+		case "oneof":
+			if r := oneofRule(t, strings.Fields(arg)); r != nil {
+				f.rules = append(f.rules, r)
+			}
+		}
+	}
+	return f, f.required || len(f.rules) > 0
+}
+
+// requestName returns the name a client knows field by: its JSON name,
+// else its query, path or header name, else the Go field name.
+func requestName(field reflect.StructField) string {
+	for _, tag := range append([]string{"json"}, bindSources...) {
+		if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" && name != "-" && name != "*" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// check returns the failure message of the field value v, which ok
+// reports to be present (see lookupField), or "".
+func (f validatedField) check(v reflect.Value, ok bool) string {
+	if !ok || !f.pointer && v.IsZero() {
+		if f.required {
+			return "is required"
+		}
+		if !ok || f.omitempty {
+			return ""
+		}
+	}
+	for _, r := range f.rules {
+		if msg := r(v); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+// emailRule requires a plain email address.
+func emailRule(v reflect.Value) string {
+	if addr, err := mail.ParseAddress(v.String()); err != nil || addr.Address != v.String() {
+		return "must be a valid email address"
+	}
+	return ""
+}
+
+// boundRule returns the min or max rule of type t with bound arg, or nil
+// if it does not apply to t.
+func boundRule(t reflect.Type, min bool, arg string) rule {
+	bound, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return nil
+	}
+	word, unit := "at most", ""
+	if min {
+		word = "at least"
+	}
+	var measure func(v reflect.Value) float64
+	switch t.Kind() {
+	case reflect.String:
+		unit = " characters"
+		measure = func(v reflect.Value) float64 { return float64(utf8.RuneCountInString(v.String())) }
+	case reflect.Slice, reflect.Map, reflect.Array:
+		unit = " items"
+		measure = func(v reflect.Value) float64 { return float64(v.Len()) }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		measure = func(v reflect.Value) float64 { return float64(v.Int()) }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		measure = func(v reflect.Value) float64 { return float64(v.Uint()) }
+	case reflect.Float32, reflect.Float64:
+		measure = func(v reflect.Value) float64 { return v.Float() }
+	default:
+		return nil
+	}
+	msg := fmt.Sprintf("must be %s %s%s", word, arg, unit)
+	return func(v reflect.Value) string {
+		if m := measure(v); min && m < bound || !min && m > bound {
+			return msg
+		}
+		return ""
+	}
+}
+
+// oneofRule returns the rule restricting a string or integer field of
+// type t to allowed, or nil if it does not apply to t.
+func oneofRule(t reflect.Type, allowed []string) rule {
+	var format func(v reflect.Value) string
+	switch t.Kind() {
+	case reflect.String:
+		format = reflect.Value.String
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		format = func(v reflect.Value) string { return strconv.FormatInt(v.Int(), 10) }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		format = func(v reflect.Value) string { return strconv.FormatUint(v.Uint(), 10) }
+	default:
+		return nil
+	}
+	msg := fmt.Sprintf("must be one of [%s]", strings.Join(allowed, " "))
+	return func(v reflect.Value) string {
+		if !contains(allowed, format(v)) {
+			return msg
+		}
+		return ""
+	}
+}
+
+// contains reports whether value is in values.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// validateBody checks a body decoded into a value other than the params
+// struct against the `validate` rules: a struct, or each struct element
+// of a JSON array, whose failing fields are reported by index, e.g.
+// "[1].email". An empty array passes.
+func validateBody(v reflect.Value) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return structFieldsOf(v.Type()).validate(v)
+	case reflect.Slice, reflect.Array:
+	default:
+		return nil
+	}
+	var failed map[string]string
+	for i := 0; i < v.Len(); i++ {
+		err, ok := validateBody(v.Index(i)).(*xmux.ValidationError)
+		if !ok {
+			continue
+		}
+		if failed == nil {
+			failed = make(map[string]string)
+		}
+		for name, msg := range err.Fields {
+			if !strings.HasPrefix(name, "[") {
+				name = "." + name
+			}
+			failed[fmt.Sprintf("[%d]%s", i, name)] = msg
+		}
+	}
+	if failed != nil {
+		return &xmux.ValidationError{Fields: failed}
+	}
+	return nil
+}
+
+// validate checks the bound values of struct v against the `validate`
+// rules, reporting every failing field in an xmux.ValidationError.
+func (f *structFields) validate(v reflect.Value) error {
+	var failed map[string]string
+	for _, field := range f.validated {
+		value, ok := lookupField(v, field.index)
+		if msg := field.check(value, ok); msg != "" {
+			if failed == nil {
+				failed = make(map[string]string)
+			}
+			failed[field.name] = msg
+		}
+	}
+	if failed != nil {
+		return &xmux.ValidationError{Fields: failed}
+	}
+	return nil
+}
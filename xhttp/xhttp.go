@@ -0,0 +1,347 @@
+// Package xhttp implements the request pipeline shared by net/http based
+// xmux adapters. A Handler binds the request, invokes the Api and renders
+// the result or error, so every framework behaves identically.
+//
+// Any framework able to mount a http.Handler can use it:
+//
+//	func (c *Controller) Handle(method, path string, api xmux.Api, opts ...map[string]string) {
+//	    c.mux.Method(method, path, xhttp.NewHandler(method, path, api, opts...))
+//	}
+package xhttp
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Just-maple/xmux"
+)
+
+// Config holds router-wide settings applied to every Handler created
+// from it. The zero Config is valid and applies no limits.
+type Config struct {
+	// RequestTimeout bounds the time spent invoking the Api.
+	// The request context carries the deadline, so handlers observe
+	// cancellation; a handler failing with context.DeadlineExceeded gets
+	// 504 Gateway Timeout, while a result returned in time is written.
+	// Zero means no timeout.
+	RequestTimeout time.Duration
+
+	// Envelope wraps every result in an xmux.Envelope, unless a route
+	// opts out with xmux.WithEnvelope(false)
+	Envelope bool
+
+	// JSON configures the encoding of response bodies
+	JSON JSONConfig
+
+	// Debug indents JSON responses with two spaces when JSON.Indent is
+	// not set, and logs responses aborted because the client disconnected
+	Debug bool
+
+	// NotFoundHandler answers requests matching no route, see NotFound
+	NotFoundHandler http.Handler
+
+	// MethodNotAllowedHandler answers requests matching a route for
+	// another method only, see MethodNotAllowed
+	MethodNotAllowedHandler http.Handler
+
+	// ValidationStatus is the status of responses to an
+	// xmux.ValidationError, e.g. 422 Unprocessable Entity; zero means
+	// 400 Bad Request. Other bind errors, such as malformed JSON, are
+	// always 400.
+	ValidationStatus int
+
+	// Version is set as the X-API-Version header of the default 404 and
+	// 405 responses, which belong to no route carrying an xmux.OptionVersion
+	Version string
+}
+
+var (
+	// ErrRequestTimeout is returned when Config.RequestTimeout expires.
+	ErrRequestTimeout = xmux.NewError(http.StatusGatewayTimeout, "")
+
+	// ErrUnsupportedMediaType is returned when the request Content-Type is
+	// not accepted by the route (see xmux.Consumes).
+	ErrUnsupportedMediaType = xmux.NewError(http.StatusUnsupportedMediaType, "")
+
+	// ErrNotAcceptable is returned when the Accept header matches none of
+	// the route's response types (see xmux.Produces).
+	ErrNotAcceptable = xmux.NewError(http.StatusNotAcceptable, "")
+
+	// ErrNotFound is answered by Config.NotFound.
+	ErrNotFound = xmux.NewError(http.StatusNotFound, "")
+
+	// ErrMethodNotAllowed is answered by Config.MethodNotAllowed.
+	ErrMethodNotAllowed = xmux.NewError(http.StatusMethodNotAllowed, "")
+)
+
+// Handler serves a single xmux route over net/http.
+type Handler struct {
+	method  string
+	pattern string
+	api     xmux.Api
+	options map[string]string
+	plan    *BindPlan
+	config  Config
+
+	// consumes lists the accepted request media types, empty for any
+	consumes []string
+
+	// produces lists the response media types, empty for unchecked
+	produces []string
+
+	// envelope wraps results in an xmux.Envelope
+	envelope bool
+
+	// fieldNames lists the JSON names of the response fields, nil if the
+	// response is not a struct, a list of structs or a page of them
+	fieldNames map[string]bool
+
+	// listField is the JSON name of the list of a page response, whose
+	// elements the fields query parameter selects from
+	listField string
+
+	// restricted reports whether the response may hold fields tagged
+	// visible
+	restricted bool
+}
+
+// NewHandler creates a Handler for the route using the zero Config.
+//
+// Parameters:
+//   - method: HTTP method the route was registered with
+//   - pattern: the route pattern (e.g., "/users/:id")
+//   - api: the type-safe handler to invoke
+//   - options: route options, later options override earlier ones
+func NewHandler(method string, pattern string, api xmux.Api, options ...map[string]string) *Handler {
+	return Config{}.NewHandler(method, pattern, api, options...)
+}
+
+// NewHandler creates a Handler for the route using c.
+// Parameters are the same as for the package level NewHandler.
+//
+// Example:
+//
+//	config := xhttp.Config{RequestTimeout: 5 * time.Second}
+//	handler := config.NewHandler(method, path, api, opts...)
+func (c Config) NewHandler(method string, pattern string, api xmux.Api, options ...map[string]string) *Handler {
+	c = c.withDefaults()
+	merged := xmux.MergeOptions(options, false)
+	if transform, ok := keyTransforms[merged[xmux.OptionKeyCase]]; ok {
+		c.JSON.KeyTransform = transform
+	}
+	h := &Handler{
+		method:     method,
+		pattern:    pattern,
+		api:        api,
+		options:    merged,
+		plan:       CompileBind(reflect.TypeOf(api.Params())),
+		config:     c,
+		consumes:   xmux.SplitOption(strings.ToLower(merged[xmux.OptionConsumes])),
+		produces:   xmux.SplitOption(strings.ToLower(merged[xmux.OptionProduces])),
+		envelope:   c.Envelope && merged[xmux.OptionEnvelope] != "false" || merged[xmux.OptionEnvelope] == "true",
+		restricted: restricted(reflect.TypeOf(api.Response())),
+	}
+	if merged[xmux.OptionSparseFields] == "true" {
+		item, list := responseItems(reflect.TypeOf(api.Response()))
+		h.fieldNames, h.listField = responseFieldNames(item), list
+	}
+	return h
+}
+
+// withDefaults returns c with the settings implied by others applied.
+func (c Config) withDefaults() Config {
+	if c.Debug && c.JSON.Indent == "" {
+		c.JSON.Indent = "  "
+	}
+	return c
+}
+
+const (
+	// HeaderRequestID is the header carrying the request id.
+	HeaderRequestID = "X-Request-ID"
+
+	// HeaderAPIVersion is the response header carrying the route's API
+	// version (see xmux.VersionedGroup).
+	HeaderAPIVersion = "X-API-Version"
+)
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r = r.WithContext(xmux.WithRoute(enrichContext(r), h.method, h.pattern, h.options))
+	if id, ok := xmux.RequestIDFromContext(r.Context()); ok {
+		w.Header().Set(HeaderRequestID, id)
+	}
+	if version := h.options[xmux.OptionVersion]; version != "" {
+		w.Header().Set(HeaderAPIVersion, version)
+	}
+	if len(h.produces) > 0 && !acceptable(h.produces, r.Header.Get("Accept")) {
+		h.handleError(w, r, ErrNotAcceptable)
+		return
+	}
+	fields, err := h.sparseFields(r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	ctx := r.Context()
+	if h.config.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.config.RequestTimeout)
+		defer cancel()
+	}
+	header := make(http.Header)
+	ctx = xmux.WithResponseHeader(ctx, header)
+	var caller xmux.Caller
+	ctx = xmux.RecordCaller(ctx, &caller)
+	b := getRequestBinder(h, r)
+	result, err := h.api.Invoke(ctx, b.fn)
+	putRequestBinder(b)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = ErrRequestTimeout
+	}
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	for k, v := range header {
+		w.Header()[k] = v
+	}
+	h.handleResponse(w, r, result, fields, caller)
+}
+
+// requestBinder binds a single request for a Handler.
+// Binders are pooled and fn is bound to the binder once, so serving a
+// request does not allocate a bind closure. An Api must not retain the
+// bind function after Invoke returns.
+type requestBinder struct {
+	h  *Handler
+	r  *http.Request
+	fn func(params any) error
+}
+
+// bind implements the bind function passed to xmux.Api.Invoke.
+//
+// A panic while binding, e.g. in a custom converter or UnmarshalJSON, is
+// recovered into a BindError of Type "bind_panic", so a single malformed
+// request answers 400 instead of crashing the handler.
+func (b *requestBinder) bind(params any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &xmux.BindError{Type: "bind_panic", Err: fmt.Errorf("%v", r)}
+		}
+	}()
+	if len(b.h.consumes) > 0 && b.r.ContentLength != 0 && !acceptsMediaType(b.h.consumes, b.r.Header.Get("Content-Type")) {
+		return ErrUnsupportedMediaType
+	}
+	return b.h.plan.bind(b.r, params, b.h.options[xmux.OptionRequireBody] == "true")
+}
+
+// acceptsMediaType reports whether the media type of contentType, with
+// parameters such as charset ignored, is one of accepted.
+func acceptsMediaType(accepted []string, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range accepted {
+		if t == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// binderPool recycles requestBinders across requests.
+var binderPool = sync.Pool{
+	New: func() any {
+		b := new(requestBinder)
+		b.fn = b.bind
+		return b
+	},
+}
+
+// acceptable reports whether the Accept header value matches one of the
+// produced media types. Wildcards ("*/*", "type/*") are honored and media
+// ranges with q=0 are excluded. An empty header accepts anything.
+func acceptable(produces []string, accept string) bool {
+	if accept == "" {
+		return true
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+			continue
+		}
+		for _, t := range produces {
+			if mediaRange == "*/*" || mediaRange == t ||
+				strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(t, strings.TrimSuffix(mediaRange, "*")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getRequestBinder borrows a requestBinder for r from the pool.
+func getRequestBinder(h *Handler, r *http.Request) *requestBinder {
+	b := binderPool.Get().(*requestBinder)
+	b.h, b.r = h, r
+	return b
+}
+
+// putRequestBinder returns b to the pool, releasing the request.
+func putRequestBinder(b *requestBinder) {
+	b.h, b.r = nil, nil
+	binderPool.Put(b)
+}
+
+// enrichContext returns the request context carrying xmux.RequestInfo
+// and the request id. The id is taken from the X-Request-ID header when
+// it is well-formed, otherwise a new one is generated.
+func enrichContext(r *http.Request) context.Context {
+	ctx := xmux.WithRequestInfo(r.Context(), &xmux.RequestInfo{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Header:     r.Header,
+		RemoteAddr: r.RemoteAddr,
+	})
+	id := r.Header.Get(HeaderRequestID)
+	if !validRequestID(id) {
+		id = newRequestID()
+	}
+	return xmux.WithRequestID(ctx, id)
+}
+
+// validRequestID reports whether a client supplied id is safe to echo.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random (version 4) UUID.
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,38 @@
+package xhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Just-maple/xmux"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	config := Config{RequestTimeout: 10 * time.Millisecond}
+	cases := []struct {
+		name string
+		fn   func(ctx context.Context, params *emptyParams) (string, error)
+		want int
+	}{
+		{"deadline exceeded", func(ctx context.Context, params *emptyParams) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}, http.StatusGatewayTimeout},
+		{"result after the deadline", func(ctx context.Context, params *emptyParams) (string, error) {
+			<-ctx.Done()
+			return "done", nil
+		}, http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			config.NewHandler(http.MethodGet, "/slow", xmux.NewHandler(tc.fn)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+			if rec.Code != tc.want {
+				t.Errorf("status %d, want %d: %s", rec.Code, tc.want, rec.Body)
+			}
+		})
+	}
+}
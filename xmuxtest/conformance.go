@@ -0,0 +1,190 @@
+// Package xmuxtest provides utilities for testing xmux adapters and
+// route wiring.
+package xmuxtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Just-maple/xmux"
+)
+
+// Adapter is a framework adapter under test: it registers routes as a
+// xmux.Controller and serves them as a http.Handler.
+type Adapter interface {
+	xmux.Controller
+	http.Handler
+}
+
+type conformanceParams struct {
+	ID    string `json:"id" path:"id"`
+	Query string `json:"-" query:"q"`
+	Limit int    `json:"-" query:"limit"`
+	Name  string `json:"name"`
+	Path  string `json:"-" path:"filepath"`
+}
+
+type conformanceResponse struct {
+	ID    string `json:"id"`
+	Query string `json:"query"`
+	Limit int    `json:"limit"`
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+}
+
+func conformanceEcho(ctx context.Context, params *conformanceParams) (*conformanceResponse, error) {
+	return &conformanceResponse{ID: params.ID, Query: params.Query, Limit: params.Limit, Name: params.Name, Path: params.Path}, nil
+}
+
+func conformanceTeapot(ctx context.Context, params *conformanceParams) (*conformanceResponse, error) {
+	return nil, xmux.NewError(http.StatusTeapot, "")
+}
+
+// RunConformance verifies that the adapter returned by newAdapter satisfies
+// the contract expected by xmux. A new adapter is created for each case.
+//
+// An adapter must:
+//   - route requests by method and pattern, with ":name" path segments
+//   - bind path parameters into fields tagged `path`
+//   - capture the rest of the path, without a leading slash, into the
+//     parameter of a trailing "*name" segment (see xhttp.WildcardName)
+//   - bind query values into fields tagged `query`, converting scalars
+//   - decode a JSON request body into the params struct, including a
+//     chunked body of unknown length
+//   - respond 200 with the JSON encoded handler result
+//   - respond with the status carried by handler errors (see xmux.StatusCode)
+//   - respond 400 when binding fails
+//
+// Example:
+//
+//	func TestAdapter(t *testing.T) {
+//	    xmuxtest.RunConformance(t, func() xmuxtest.Adapter { return NewController() })
+//	}
+func RunConformance(t *testing.T, newAdapter func() Adapter) {
+	t.Helper()
+
+	cases := []struct {
+		name       string
+		method     string
+		pattern    string
+		handler    func(context.Context, *conformanceParams) (*conformanceResponse, error)
+		target     string
+		body       string
+		chunked    bool
+		wantStatus int
+		want       *conformanceResponse
+	}{
+		{
+			name:       "path and query",
+			method:     http.MethodGet,
+			pattern:    "/conformance/items/:id",
+			handler:    conformanceEcho,
+			target:     "/conformance/items/42?q=search&limit=5",
+			wantStatus: http.StatusOK,
+			want:       &conformanceResponse{ID: "42", Query: "search", Limit: 5},
+		},
+		{
+			name:       "catch-all",
+			method:     http.MethodGet,
+			pattern:    "/conformance/files/*filepath",
+			handler:    conformanceEcho,
+			target:     "/conformance/files/css/site.css",
+			wantStatus: http.StatusOK,
+			want:       &conformanceResponse{Path: "css/site.css"},
+		},
+		{
+			name:       "json body",
+			method:     http.MethodPost,
+			pattern:    "/conformance/items",
+			handler:    conformanceEcho,
+			target:     "/conformance/items",
+			body:       `{"name":"widget"}`,
+			wantStatus: http.StatusOK,
+			want:       &conformanceResponse{Name: "widget"},
+		},
+		{
+			name:       "chunked body",
+			method:     http.MethodPost,
+			pattern:    "/conformance/items",
+			handler:    conformanceEcho,
+			target:     "/conformance/items",
+			body:       `{"name":"streamed"}`,
+			chunked:    true,
+			wantStatus: http.StatusOK,
+			want:       &conformanceResponse{Name: "streamed"},
+		},
+		{
+			name:       "path and body",
+			method:     http.MethodPut,
+			pattern:    "/conformance/items/:id",
+			handler:    conformanceEcho,
+			target:     "/conformance/items/7",
+			body:       `{"name":"renamed"}`,
+			wantStatus: http.StatusOK,
+			want:       &conformanceResponse{ID: "7", Name: "renamed"},
+		},
+		{
+			name:       "error status",
+			method:     http.MethodGet,
+			pattern:    "/conformance/teapot",
+			handler:    conformanceTeapot,
+			target:     "/conformance/teapot",
+			wantStatus: http.StatusTeapot,
+		},
+		{
+			name:       "invalid query",
+			method:     http.MethodGet,
+			pattern:    "/conformance/items",
+			handler:    conformanceEcho,
+			target:     "/conformance/items?limit=many",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "malformed body",
+			method:     http.MethodPost,
+			pattern:    "/conformance/items",
+			handler:    conformanceEcho,
+			target:     "/conformance/items",
+			body:       `{"name":`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			adapter := newAdapter()
+			adapter.Handle(tc.method, tc.pattern, xmux.NewHandler(tc.handler))
+
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if tc.chunked {
+				// The length of a chunked body is unknown
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+			rec := httptest.NewRecorder()
+			adapter.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("%s %s: status = %d, want %d (body %q)", tc.method, tc.target, rec.Code, tc.wantStatus, rec.Body.String())
+			}
+			if tc.want == nil {
+				return
+			}
+			var got conformanceResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("%s %s: decode response: %v (body %q)", tc.method, tc.target, err, rec.Body.String())
+			}
+			if got != *tc.want {
+				t.Fatalf("%s %s: response = %+v, want %+v", tc.method, tc.target, got, *tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package xmuxtest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/Just-maple/xmux"
+)
+
+// Invoke calls api directly with params, without HTTP, and returns the
+// typed response. It is a shorthand for InvokeContext with
+// context.Background().
+//
+// Example:
+//
+//	mock := &xmuxtest.MockRouter{}
+//	xmux.Register(mock, http.MethodPost, "/users", svc.CreateUser)
+//	reg, _ := mock.Find(http.MethodPost, "/users")
+//	resp, err := xmuxtest.Invoke[*CreateUserRequest, *UserResponse](reg.Api, &CreateUserRequest{Name: "john"})
+func Invoke[Params any, Response any](api xmux.Api, params Params) (Response, error) {
+	return InvokeContext[Params, Response](context.Background(), api, params)
+}
+
+// InvokeContext calls api with ctx and a bind function that populates the
+// handler params from params.
+//
+// params may be the handler's param type itself, a pointer to it, or the
+// type of one of its embedded fields; embedded pointers are allocated as
+// needed.
+func InvokeContext[Params any, Response any](ctx context.Context, api xmux.Api, params Params) (resp Response, err error) {
+	result, err := api.Invoke(ctx, func(ptr any) error {
+		return assign(ptr, params)
+	})
+	if err != nil {
+		return resp, err
+	}
+	if result == nil {
+		return resp, nil
+	}
+	resp, ok := result.(Response)
+	if !ok {
+		return resp, fmt.Errorf("xmuxtest: response is %T, not %T", result, resp)
+	}
+	return resp, nil
+}
+
+// assign stores value into the variable ptr points to.
+func assign(ptr any, value any) error {
+	dst := reflect.ValueOf(ptr)
+	if dst.Kind() != reflect.Pointer || dst.IsNil() {
+		return fmt.Errorf("xmuxtest: bind target %T is not a pointer", ptr)
+	}
+	if value == nil {
+		return nil
+	}
+	if set(dst.Elem(), reflect.ValueOf(value)) {
+		return nil
+	}
+	return fmt.Errorf("xmuxtest: cannot bind %T into %T", value, ptr)
+}
+
+// set assigns src to dst, dereferencing src and allocating pointers in dst
+// until the types match. Embedded fields of struct targets are searched
+// for a matching type. dst is left untouched if no assignment is possible.
+func set(dst reflect.Value, src reflect.Value) bool {
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return true
+	}
+	if src.Kind() == reflect.Pointer {
+		return src.IsNil() || set(dst, src.Elem())
+	}
+	if dst.Kind() == reflect.Pointer {
+		if !dst.IsNil() {
+			return set(dst.Elem(), src)
+		}
+		elem := reflect.New(dst.Type().Elem())
+		if !set(elem.Elem(), src) {
+			return false
+		}
+		dst.Set(elem)
+		return true
+	}
+	if dst.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < dst.NumField(); i++ {
+		if dst.Type().Field(i).Anonymous && dst.Field(i).CanSet() && set(dst.Field(i), src) {
+			return true
+		}
+	}
+	return false
+}
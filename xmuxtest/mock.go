@@ -0,0 +1,85 @@
+package xmuxtest
+
+import (
+	"sync"
+
+	"github.com/Just-maple/xmux"
+)
+
+// Registration is a route recorded by MockRouter.
+type Registration struct {
+	// Method is the HTTP method of the route
+	Method string
+
+	// Path is the route pattern
+	Path string
+
+	// Api is the registered handler
+	Api xmux.Api
+
+	// Options are the route options in registration order
+	Options []map[string]string
+}
+
+// MergedOptions returns the route options merged with later options
+// overriding earlier ones, as adapters see them.
+func (r Registration) MergedOptions() map[string]string {
+	return xmux.MergeOptions(r.Options, false)
+}
+
+// MockRouter records route registrations for unit tests.
+// It implements both xmux.Router and xmux.Controller, so it can be passed
+// to Register directly or bound by groups:
+//
+//	mock := &xmuxtest.MockRouter{}
+//	err := userGroup.Bind(mock, bindServices)
+//	reg, ok := mock.Find(http.MethodGet, "/users/:id")
+//
+// It is safe for concurrent use.
+type MockRouter struct {
+	mu            sync.Mutex
+	registrations []Registration
+}
+
+// Register implements xmux.Router.
+func (m *MockRouter) Register(method string, path string, api xmux.Api, options ...map[string]string) {
+	m.mu.Lock()
+	m.registrations = append(m.registrations, Registration{
+		Method:  method,
+		Path:    path,
+		Api:     api,
+		Options: append([]map[string]string(nil), options...),
+	})
+	m.mu.Unlock()
+}
+
+// Handle implements xmux.Controller.
+func (m *MockRouter) Handle(method string, path string, api xmux.Api, options ...map[string]string) {
+	m.Register(method, path, api, options...)
+}
+
+// Registrations returns a copy of the recorded registrations in order.
+func (m *MockRouter) Registrations() []Registration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Registration(nil), m.registrations...)
+}
+
+// Find returns the registration for method and path.
+func (m *MockRouter) Find(method string, path string) (Registration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.registrations {
+		if r.Method == method && r.Path == path {
+			return r, true
+		}
+	}
+	return Registration{}, false
+}
+
+// Reset discards all recorded registrations.
+func (m *MockRouter) Reset() {
+	m.mu.Lock()
+	m.registrations = nil
+	m.mu.Unlock()
+}